@@ -79,6 +79,28 @@ type ActiveCommandChain struct {
 	log       *log.Entry
 
 	executing bool
+
+	timings    []StageTiming
+	stageStart time.Time
+}
+
+// StageTiming is the duration of a stage of a command chain.
+type StageTiming struct {
+	Stage    string        `json:"stage"`
+	Duration time.Duration `json:"duration"`
+	Failed   bool          `json:"failed,omitempty"`
+}
+
+// Timings returns the durations of the executed stages.
+func (a *ActiveCommandChain) Timings() []StageTiming { return a.timings }
+
+// endStage records the duration of the current stage.
+func (a *ActiveCommandChain) endStage(failed bool) {
+	if a.lastStage == "" || a.stageStart.IsZero() {
+		return
+	}
+	a.timings = append(a.timings, StageTiming{Stage: a.lastStage, Duration: time.Since(a.stageStart), Failed: failed})
+	a.stageStart = time.Time{}
 }
 
 // Logger returns the logger for the command chain.
@@ -114,8 +136,10 @@ func (a *ActiveCommandChain) Exec() error {
 	for _, f := range a.funcs {
 		if f.f == nil {
 			if f.s != "" {
+				a.endStage(false)
 				a.log.Println(f.s, "...")
 				a.lastStage = f.s
+				a.stageStart = time.Now()
 			}
 			continue
 		}
@@ -137,11 +161,13 @@ func (a *ActiveCommandChain) Exec() error {
 		}
 
 		// error
+		a.endStage(true)
 		if a.lastStage == "" {
 			return err
 		}
 		return fmt.Errorf("error at '%s': %w", a.lastStage, err)
 	}
+	a.endStage(false)
 	return nil
 }
 
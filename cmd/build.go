@@ -0,0 +1,739 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/zenginechris/devx/cli"
+	"github.com/zenginechris/devx/cmd/root"
+	"github.com/zenginechris/devx/config"
+	"github.com/zenginechris/devx/internal/buildkit"
+	"github.com/zenginechris/devx/internal/clients"
+	"github.com/zenginechris/devx/internal/filesystem"
+	"github.com/zenginechris/devx/internal/git"
+	"github.com/zenginechris/devx/internal/history"
+	"github.com/zenginechris/devx/internal/projects"
+)
+
+func init() {
+	buildProjectCmd.Flags().BoolVarP(&buildProjectCmdArgs.force, "force", "f", false, "build and deploy even if the cluster already runs the current context")
+	buildProjectCmd.Flags().StringVar(&buildProjectCmdArgs.contextMode, "context-mode", "", "how the context is passed to the builder: stream, dir or staging")
+	buildProjectCmd.Flags().BoolVar(&buildProjectCmdArgs.ifChanged, "if-changed", false, "only build if the context changed since the last successful build")
+	buildProjectCmd.Flags().StringArrayVar(&buildProjectCmdArgs.buildArgs, "build-arg", nil, "set a build arg, overrides the project configuration (KEY=VALUE)")
+	buildProjectCmd.Flags().StringVar(&buildProjectCmdArgs.target, "target", "", "set the target build stage, overrides the project configuration")
+	buildProjectCmd.Flags().IntVar(&buildProjectCmdArgs.copyWorkers, "copy-workers", 0, "number of concurrent file copies in dir mode (default number of CPUs)")
+	buildProjectCmd.Flags().StringVar(&buildProjectCmdArgs.builder, "builder", "", "name of the buildx builder, overrides the project configuration")
+	buildProjectCmd.Flags().BoolVar(&buildProjectCmdArgs.report, "report", false, "compare the duration of each build step with the previous build")
+	buildProjectCmd.Flags().BoolVar(&buildProjectCmdArgs.refreshPlatforms, "refresh-platforms", false, "query the node platforms of the cluster instead of using the cached result")
+	root.Cmd().AddCommand(buildProjectCmd)
+}
+
+var buildProjectCmdArgs struct {
+	force       bool
+	ifChanged   bool
+	contextMode string
+	copyWorkers int
+	buildArgs   []string
+	target      string
+	builder     string
+
+	refreshPlatforms bool
+	report           bool
+}
+
+var buildProjectCmd = &cobra.Command{
+	Use:     "build",
+	Aliases: []string{"b"},
+	Args:    cobra.MinimumNArgs(1),
+	Short:   "Build and update",
+	Long:    "Build a new image from the current context and updates the image in the current k8s cluster",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		project, err := loadProject(args[0])
+		if err != nil {
+			return err
+		}
+
+		contextMode := project.ContextMode
+		if buildProjectCmdArgs.contextMode != "" {
+			contextMode = buildProjectCmdArgs.contextMode
+		}
+		if contextMode == "" {
+			contextMode = projects.ContextModeStream
+		}
+		switch contextMode {
+		case projects.ContextModeStream, projects.ContextModeDir, projects.ContextModeStaging:
+		default:
+			return fmt.Errorf("invalid context mode '%s', expected %s, %s or %s", contextMode,
+				projects.ContextModeStream, projects.ContextModeDir, projects.ContextModeStaging)
+		}
+
+		if err := applyBuildOverrides(&project.Build); err != nil {
+			return err
+		}
+
+		r := &buildRun{
+			project:     project,
+			contextMode: contextMode,
+			assembler:   filesystem.NewContextAssembler(contextSources(project)...),
+		}
+
+		if err := r.startRecord(); err != nil {
+			logrus.Warn(fmt.Sprintf("Build will not be recorded in the history: %v", err))
+		}
+		status, err := r.run(cmd.Context())
+		if err != nil {
+			r.runFailureHooks(cmd.Context(), err)
+		}
+		r.finishRecord(status, err)
+		return err
+	},
+}
+
+// run runs the build and returns its history status.
+func (r *buildRun) run(ctx context.Context) (string, error) {
+	project := r.project
+
+	prepare := cli.New("build").Init(ctx)
+	r.log = prepare.Logger()
+
+	r.addHooks(prepare, "pre_build", project.Hooks.PreBuild)
+	prepare.Stage("assembling build context")
+	prepare.Add(r.assemble)
+	prepare.Stage("checking Dockerfile")
+	prepare.Add(r.lint)
+	prepare.Stage("inspecting builder and computing context digest")
+	prepare.Add(r.computeDigest)
+	err := prepare.Exec()
+	r.record.Stages = append(r.record.Stages, prepare.Timings()...)
+	if err != nil {
+		return history.StatusFailed, err
+	}
+
+	if buildProjectCmdArgs.ifChanged && !r.contextChanged() {
+		r.log.Infof("Context of %s did not change since the last build, nothing to do", project.Name)
+		return history.StatusSkipped, nil
+	}
+
+	if !buildProjectCmdArgs.force && deploymentRunsContext(project, r.digest) {
+		r.log.Infof("Deployment %s already runs context digest %s, nothing to do (use --force to rebuild)", project.DeploymentName, r.digest[:12])
+		return history.StatusSkipped, nil
+	}
+
+	build := cli.New("build").Init(ctx)
+	if len(project.Generate) > 0 {
+		defer r.removeGeneratedFiles()
+		build.Stage("generating files")
+		build.Add(r.generateFiles)
+	}
+	build.Stagef("building image %s", r.image)
+	build.Add(r.build)
+	build.Stage("analyzing image size")
+	build.Add(r.analyzeImage)
+	r.addHooks(build, "post_build", project.Hooks.PostBuild)
+	build.Stage("updating deployment")
+	build.Add(func() error {
+		return clients.UpdateDeployment(project, r.image, r.annotations())
+	})
+	build.Add(func() error {
+		return pruneOldImages(r.log, project)
+	})
+	build.Add(func() error {
+		// a missing manifest only causes the next --if-changed build to run
+		if err := r.manifest.Save(manifestFile(project)); err != nil {
+			return cli.ErrNonFatal(fmt.Errorf("error saving build manifest: %w", err))
+		}
+		return nil
+	})
+	r.addHooks(build, "post_deploy", project.Hooks.PostDeploy)
+	err = build.Exec()
+	r.record.Stages = append(r.record.Stages, build.Timings()...)
+	if err != nil {
+		return history.StatusFailed, err
+	}
+	return history.StatusSuccess, nil
+}
+
+// buildRun holds the state of a single build shared between the stages.
+type buildRun struct {
+	project     projects.Project
+	contextMode string
+	log         *logrus.Entry
+
+	assembler *filesystem.ContextAssembler
+	entries   []filesystem.Entry
+	ignored   []filesystem.Ignored
+	manifest  filesystem.Manifest
+	// previous is the manifest of the last successful build, if any
+	previous filesystem.Manifest
+
+	builder   clients.Builder
+	platforms string
+	image     string
+	digest    string
+	cacheDir  string
+	git       *git.Info
+
+	generatedDir string
+
+	record  history.Record
+	logFile *os.File
+	logOut  io.Writer
+	hooks   logrus.LevelHooks
+}
+
+// assemble collects the entries of the build context.
+func (r *buildRun) assemble() error {
+	entries, ignored, err := r.assembler.Assemble()
+	if err != nil {
+		return err
+	}
+
+	if !filesystem.HasEntry(entries, "Dockerfile") {
+		return fmt.Errorf("no Dockerfile found in the build context, make sure one of your source paths contains a Dockerfile")
+	}
+
+	r.entries, r.ignored = entries, ignored
+	return nil
+}
+
+// computeDigest inspects the builder, selects the platforms and determines
+// the image tag from the context digest.
+func (r *buildRun) computeDigest() error {
+	builder, err := clients.InspectBuilder(r.project.Build.Builder)
+	if err != nil {
+		if r.project.Build.Builder != "" {
+			return err
+		}
+		r.log.Warnf("%v, assuming the docker driver", err)
+		builder = clients.Builder{Driver: clients.DriverDocker}
+	}
+	r.builder = builder
+	r.log.Debugf("Using builder %s with driver %s", builder.Name, builder.Driver)
+
+	platforms := r.project.Build.Platforms
+	if len(platforms) == 0 {
+		platforms = clusterPlatforms(r.log, buildProjectCmdArgs.refreshPlatforms)
+	}
+	if len(platforms) > 1 && builder.Driver == clients.DriverDocker {
+		platform := preferredPlatform(builder, platforms)
+		r.log.Warnf("The docker driver cannot build for %s, building for %s only", strings.Join(platforms, ","), platform)
+		platforms = []string{platform}
+	}
+	for _, p := range platforms {
+		if !builder.Supports(p) {
+			r.log.Warnf("Builder %s does not support %s, the build may fail without QEMU emulation", builder.Name, p)
+		}
+	}
+	r.platforms = strings.Join(platforms, ",")
+
+	// files unchanged since the last build are not hashed again
+	previous, err := filesystem.LoadManifest(manifestFile(r.project))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			r.log.Warn(err)
+		}
+		previous = filesystem.Manifest{}
+	}
+	r.previous = previous
+
+	manifest, err := filesystem.ManifestOf(r.entries, previous)
+	if err != nil {
+		return err
+	}
+	r.manifest = manifest
+
+	info, ok, err := git.Inspect(r.project.Context)
+	if err != nil {
+		r.log.Warnf("Could not read the git state of %s: %v", r.project.Context, err)
+	}
+	r.digest = manifest.Digest(r.digestInputs()...)
+	data := projects.TagData{
+		Project:   slugify(r.project.Name),
+		Digest:    r.digest[:12],
+		Timestamp: time.Now().UnixMilli(),
+	}
+	if ok {
+		r.git = &info
+		data.Commit, data.Branch, data.Dirty = info.ShortCommit(), info.Branch, info.Dirty
+	}
+
+	tag, err := r.project.ImageTag(data)
+	if err != nil {
+		return err
+	}
+	r.image = fmt.Sprintf("devx_%s:%s", data.Project, tag)
+	return nil
+}
+
+// annotations returns the annotations of the deployment and its pod template,
+// recording who deployed which build and the git labels of the image. The
+// build time changes the pod template, so every deploy restarts the pods, even
+// with an unchanged image tag.
+func (r *buildRun) annotations() map[string]string {
+	version := config.AppVersion()
+	annotations := map[string]string{
+		clients.AnnotationProject: r.project.Name,
+		clients.AnnotationBuiltAt: currentTimeRFC3339(),
+		clients.AnnotationContext: r.project.Context,
+		clients.AnnotationDigest:  r.digest,
+		clients.AnnotationUser:    hostUser(),
+		clients.AnnotationVersion: version.Version + " (" + version.Revision + ")",
+	}
+	if r.git != nil {
+		revision := r.git.Commit
+		if r.git.Dirty {
+			revision += "-dirty"
+		}
+		annotations[clients.AnnotationRevision] = revision
+	}
+	maps.Copy(annotations, r.gitLabels())
+	return annotations
+}
+
+// hostUser returns user@host of the user running devx.
+func hostUser() string {
+	name := os.Getenv("USER")
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	if host, err := os.Hostname(); err == nil {
+		return name + "@" + host
+	}
+	return name
+}
+
+// gitLabels returns the OCI labels of the git work tree of the context.
+// They are set on the image and as annotations on the pod template.
+func (r *buildRun) gitLabels() map[string]string {
+	if r.git == nil {
+		return nil
+	}
+	labels := map[string]string{"org.opencontainers.image.revision": r.git.Commit}
+	if r.git.Source != "" {
+		labels["org.opencontainers.image.source"] = r.git.Source
+	}
+	if r.git.Branch != "" {
+		labels["org.opencontainers.image.ref.name"] = r.git.Branch
+	}
+	return labels
+}
+
+// contextChanged reports whether the context differs from the last successful build.
+func (r *buildRun) contextChanged() bool {
+	return r.previous.Files == nil || !r.manifest.Diff(r.previous).Empty()
+}
+
+// digestInputs returns the build settings that are part of the image tag.
+func (r *buildRun) digestInputs() []string {
+	b := r.project.Build
+	inputs := []string{"platform=" + r.platforms, "target=" + b.Target}
+	for _, k := range projects.SortedKeys(b.Args) {
+		inputs = append(inputs, "arg:"+k+"="+b.Args[k])
+	}
+	for _, k := range projects.SortedKeys(b.Labels) {
+		inputs = append(inputs, "label:"+k+"="+b.Labels[k])
+	}
+	return inputs
+}
+
+// buildOpts returns the docker buildx arguments without the context.
+func (r *buildRun) buildOpts() ([]string, error) {
+	b := r.project.Build
+
+	buildOpts := []string{
+		"buildx",
+		"build",
+		"--platform=" + r.platforms,
+		"--tag", r.image,
+		"--build-arg", "BUILD_DATE=" + currentTimeRFC3339(),
+		"--label", "org.opencontainers.image.created=" + currentTimeRFC3339(),
+		"--progress=plain",
+		"--load",
+	}
+
+	if r.builder.Name != "" {
+		buildOpts = append(buildOpts, "--builder", r.builder.Name)
+	}
+
+	cacheOpts, err := r.cacheOpts()
+	if err != nil {
+		return nil, err
+	}
+	buildOpts = append(buildOpts, cacheOpts...)
+
+	// only single platform images can be loaded into the docker image store
+	if r.builder.Driver != clients.DriverDocker && strings.Contains(r.platforms, ",") {
+		buildOpts = removeOption(buildOpts, "--load")
+	}
+
+	if b.Target != "" {
+		buildOpts = append(buildOpts, "--target", b.Target)
+	}
+	for _, k := range projects.SortedKeys(b.Args) {
+		buildOpts = append(buildOpts, "--build-arg", k+"="+b.Args[k])
+	}
+	gitLabels := r.gitLabels()
+	for _, k := range projects.SortedKeys(gitLabels) {
+		buildOpts = append(buildOpts, "--label", k+"="+gitLabels[k])
+	}
+	for _, k := range projects.SortedKeys(b.Labels) {
+		buildOpts = append(buildOpts, "--label", k+"="+b.Labels[k])
+	}
+	for _, secret := range b.Secrets {
+		flag, err := secret.Flag(r.project)
+		if err != nil {
+			return nil, err
+		}
+		buildOpts = append(buildOpts, "--secret", flag)
+	}
+	for _, ssh := range b.SSH {
+		buildOpts = append(buildOpts, "--ssh", ssh)
+	}
+
+	return buildOpts, nil
+}
+
+// cacheOpts returns the docker buildx arguments of the build cache. The local
+// cache is exported to a new directory that replaces the previous cache after
+// a successful build, so blobs of old builds do not pile up.
+func (r *buildRun) cacheOpts() ([]string, error) {
+	c := r.project.Build.Cache
+	mode, err := c.CacheMode()
+	if err != nil {
+		return nil, err
+	}
+	docker := r.builder.Driver == clients.DriverDocker
+
+	switch mode {
+	case projects.CacheModeLocal:
+		if docker {
+			r.log.Debug("The docker driver does not support the local build cache")
+			return nil, nil
+		}
+		dir := buildCacheDir(r.project)
+		if r.cacheDir, err = newCacheDir(r.project); err != nil {
+			return nil, err
+		}
+		opts := []string{"--cache-to=type=local,dest=" + r.cacheDir + ",mode=max"}
+		if _, err := os.Stat(filepath.Join(dir, "index.json")); err == nil {
+			opts = append(opts, "--cache-from=type=local,src="+dir)
+		}
+		return opts, nil
+
+	case projects.CacheModeRegistry:
+		opts := []string{"--cache-from=type=registry,ref=" + c.Ref}
+		if docker {
+			r.log.Warn("The docker driver cannot export the build cache to a registry, use a docker-container builder")
+			return opts, nil
+		}
+		return append(opts, "--cache-to=type=registry,ref="+c.Ref+",mode=max"), nil
+
+	case projects.CacheModeInline:
+		opts := []string{"--cache-to=type=inline"}
+		if c.Ref != "" {
+			opts = append(opts, "--cache-from=type=registry,ref="+c.Ref)
+		}
+		return opts, nil
+	}
+
+	return nil, nil
+}
+
+// commitCache replaces the local build cache with the cache exported by the
+// build and marks the caches of the project as used.
+func (r *buildRun) commitCache(buildErr error) error {
+	if r.cacheDir != "" {
+		// the builder exports nothing if the build fails early
+		_, err := os.Stat(filepath.Join(r.cacheDir, "index.json"))
+		if buildErr != nil || err != nil {
+			return os.RemoveAll(r.cacheDir)
+		}
+		if err := replaceCacheDir(r.cacheDir, buildCacheDir(r.project)); err != nil {
+			return err
+		}
+	}
+	if buildErr != nil {
+		return nil
+	}
+
+	now := time.Now()
+	for _, dir := range []string{buildCacheDir(r.project), stagingDir(r.project)} {
+		if err := os.Chtimes(dir, now, now); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// build runs the image build.
+func (r *buildRun) build() error {
+	buildOpts, err := r.buildOpts()
+	if err != nil {
+		return err
+	}
+
+	var (
+		dockerCmd *exec.Cmd
+		// streamErr receives the result of writing the context to the builder in stream mode
+		streamErr chan error
+		stream    *io.PipeReader
+	)
+	switch r.contextMode {
+	case projects.ContextModeDir:
+		tempDir, err := os.MkdirTemp("", "docker-build-*")
+		if err != nil {
+			return fmt.Errorf("failed to create temp directory: %w", err)
+		}
+		r.log.Debugf("Created temporary directory: %s", tempDir)
+
+		defer func() {
+			r.log.Debugf("Cleaning up temporary directory: %s", tempDir)
+			os.RemoveAll(tempDir)
+		}()
+
+		copier := filesystem.Copier{Workers: buildProjectCmdArgs.copyWorkers, Log: r.log}
+		stats, err := copier.Copy(r.entries, tempDir)
+		if err != nil {
+			return err
+		}
+		r.log.Infof("Copied %d files (%s) to %s in %s, %d paths ignored",
+			stats.Files, filesystem.FormatBytes(stats.Bytes), tempDir, stats.Duration.Round(time.Millisecond), len(r.ignored))
+
+		dockerCmd = exec.Command("docker", append(buildOpts, ".")...)
+		dockerCmd.Dir = tempDir
+
+	case projects.ContextModeStaging:
+		dir := stagingDir(r.project)
+
+		start := time.Now()
+		stats, err := filesystem.Sync(dir, r.entries)
+		if err != nil {
+			return fmt.Errorf("failed to sync staging directory %s: %w", dir, err)
+		}
+		r.log.Infof("Synced staging directory %s in %s: %d unchanged, %d copied, %d linked, %d removed",
+			dir, time.Since(start).Round(time.Millisecond), stats.Unchanged, stats.Copied, stats.Linked, stats.Removed)
+
+		dockerCmd = exec.Command("docker", append(buildOpts, ".")...)
+		dockerCmd.Dir = dir
+
+	default:
+		pr, pw := io.Pipe()
+		stream, streamErr = pr, make(chan error, 1)
+		go func() {
+			err := filesystem.WriteTar(pw, r.entries)
+			pw.CloseWithError(err)
+			streamErr <- err
+		}()
+		defer pr.Close()
+
+		dockerCmd = exec.Command("docker", append(buildOpts, "-")...)
+		dockerCmd.Stdin = pr
+	}
+	progress := buildkit.NewProgress()
+	dockerCmd.Stdout = os.Stdout
+	dockerCmd.Stderr = io.MultiWriter(os.Stderr, progress)
+	if r.logOut != nil {
+		dockerCmd.Stdout = io.MultiWriter(os.Stdout, r.logOut)
+		dockerCmd.Stderr = io.MultiWriter(os.Stderr, r.logOut, progress)
+	}
+	defer func() { r.record.Steps = progress.Timings() }()
+
+	err = dockerCmd.Run()
+	if streamErr != nil {
+		// unblock the writer if the builder stopped reading early
+		stream.Close()
+		if tarErr := <-streamErr; tarErr != nil && !errors.Is(tarErr, io.ErrClosedPipe) {
+			r.log.Errorf("Error streaming the build context: %v", tarErr)
+			if err == nil {
+				err = tarErr
+			}
+		}
+	}
+	if cacheErr := r.commitCache(err); cacheErr != nil {
+		r.log.Warnf("Could not update the build cache: %v", cacheErr)
+	}
+	if err != nil {
+		return buildFailure(progress, err)
+	}
+
+	r.log.Info("Docker build completed successfully")
+	r.printStepReport(progress.Timings())
+	return nil
+}
+
+// pruneOldImages applies the image retention policy of project after a deploy.
+func pruneOldImages(log *logrus.Entry, project projects.Project) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return cli.ErrNonFatal(err)
+	}
+	keep := cfg.KeepImagesFor(project)
+	if keep <= 0 {
+		return nil
+	}
+
+	inUse, err := clients.ClusterImages()
+	if err != nil {
+		return cli.ErrNonFatal(fmt.Errorf("not removing old images, cannot determine the images used in the cluster: %w", err))
+	}
+	if _, err := pruneImages(log, slugify(project.Name), keep, inUse, false); err != nil {
+		return cli.ErrNonFatal(fmt.Errorf("error removing old images: %w", err))
+	}
+	return nil
+}
+
+// applyBuildOverrides applies the build flags to the build configuration of a project.
+func applyBuildOverrides(b *projects.BuildConfig) error {
+	if buildProjectCmdArgs.target != "" {
+		b.Target = buildProjectCmdArgs.target
+	}
+	if buildProjectCmdArgs.builder != "" {
+		b.Builder = buildProjectCmdArgs.builder
+	}
+
+	if len(buildProjectCmdArgs.buildArgs) == 0 {
+		return nil
+	}
+
+	args := make(map[string]string, len(b.Args)+len(buildProjectCmdArgs.buildArgs))
+	maps.Copy(args, b.Args)
+	for _, arg := range buildProjectCmdArgs.buildArgs {
+		k, v, ok := strings.Cut(arg, "=")
+		if !ok || k == "" {
+			return fmt.Errorf("invalid build arg '%s', expected KEY=VALUE", arg)
+		}
+		args[k] = v
+	}
+	b.Args = args
+	return nil
+}
+
+// buildCacheDir returns the local build cache directory of project.
+func buildCacheDir(project projects.Project) string {
+	return filepath.Join(config.BuildCacheDir(), slugify(project.Name))
+}
+
+// newCacheDirSuffix is part of the names of the directories new build caches
+// are written to before they replace the build cache of a project.
+const newCacheDirSuffix = ".new-"
+
+// newCacheDir creates a unique directory next to the build cache directory of
+// project, so concurrent builds and imports never write to the same directory.
+func newCacheDir(project projects.Project) (string, error) {
+	dir := buildCacheDir(project)
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return "", fmt.Errorf("error creating build cache directory: %w", err)
+	}
+	tmp, err := os.MkdirTemp(filepath.Dir(dir), filepath.Base(dir)+newCacheDirSuffix+"*")
+	if err != nil {
+		return "", fmt.Errorf("error creating build cache directory: %w", err)
+	}
+	return tmp, os.Chmod(tmp, 0755)
+}
+
+// replaceCacheDir replaces the build cache directory dir with the new cache in
+// tmp. The old cache is moved aside first, so dir is never partially removed.
+// tmp is removed if it cannot replace dir.
+func replaceCacheDir(tmp, dir string) error {
+	old := tmp + ".old"
+	if err := os.Rename(dir, old); err != nil && !os.IsNotExist(err) {
+		os.RemoveAll(tmp)
+		return fmt.Errorf("error removing old build cache: %w", err)
+	}
+	defer os.RemoveAll(old)
+
+	if err := os.Rename(tmp, dir); err != nil {
+		os.RemoveAll(tmp)
+		return fmt.Errorf("error replacing build cache: %w", err)
+	}
+	return nil
+}
+
+// stagingDir returns the staging directory of project.
+func stagingDir(project projects.Project) string {
+	return filepath.Join(config.StagingDir(), slugify(project.Name))
+}
+
+// manifestFile returns the file of the manifest of the last successful build of project.
+func manifestFile(project projects.Project) string {
+	return filepath.Join(config.ManifestsDir(), slugify(project.Name)+".json")
+}
+
+// contextSources returns the sources the build context of project is assembled from.
+func contextSources(project projects.Project) []filesystem.ContextSource {
+	dockerfile := project.DockerfilePath()
+
+	sources := []filesystem.ContextSource{
+		{
+			Path:           project.Context,
+			IgnoreFile:     filesystem.DockerignoreFile(project.Context, dockerfile),
+			Ignore:         project.Ignore,
+			Gitignore:      project.Gitignore,
+			FollowSymlinks: project.FollowSymlinks,
+		},
+		{Path: dockerfile, Dest: "Dockerfile"},
+	}
+
+	for _, c := range project.Contexts {
+		source := filesystem.ContextSource{
+			Path:           c.Src,
+			Dest:           c.Dest,
+			Ignore:         c.Ignore,
+			Gitignore:      project.Gitignore,
+			FollowSymlinks: project.FollowSymlinks,
+		}
+		if file := c.IgnoreFilePath(project); file != "" {
+			source.IgnoreFiles = []string{file}
+		}
+		sources = append(sources, source)
+	}
+
+	return sources
+}
+
+// deploymentRunsContext reports whether every container of the project
+// deployment runs an image of the project built from a context with digest.
+// Image tags are not compared, as they change with the git state of the whole
+// work tree. Lookup failures are logged and treated as a mismatch.
+func deploymentRunsContext(project projects.Project, digest string) bool {
+	images, annotations, err := clients.DeploymentImages(project)
+	if err != nil {
+		logrus.Warn(fmt.Sprintf("Could not look up deployment images: %v", err))
+		return false
+	}
+	if len(images) == 0 || annotations[clients.AnnotationDigest] != digest {
+		return false
+	}
+	repository := imageRepository(slugify(project.Name)) + ":"
+	for _, img := range images {
+		if !strings.HasPrefix(img, repository) {
+			return false
+		}
+	}
+	return true
+}
+
+func currentTimeRFC3339() string {
+	return time.Now().Format(time.RFC3339)
+}
+
+func removeOption(slice []string, option string) []string {
+	for i, item := range slice {
+		if item == option {
+			return slices.Delete(slice, i, i+1)
+		}
+	}
+	return slice
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/zenginechris/devx/cmd/root"
+	"github.com/zenginechris/devx/config"
+	"github.com/zenginechris/devx/internal/clients"
+)
+
+// defaultBuilderName is the name of the builder created by devx builder create.
+const defaultBuilderName = "devx"
+
+func init() {
+	builderCreateCmd.Flags().IntVar(&builderCreateCmdArgs.keepStorage, "keep-storage", 20, "build cache size in GB kept by the builder's garbage collection")
+	builderCmd.AddCommand(builderCreateCmd)
+	root.Cmd().AddCommand(builderCmd)
+}
+
+var builderCmd = &cobra.Command{
+	Use:   "builder",
+	Short: "Manage buildx builders",
+	Long:  "Manage the docker buildx builders used to build project images",
+}
+
+var builderCreateCmdArgs struct {
+	keepStorage int
+}
+
+var builderCreateCmd = &cobra.Command{
+	Use:   "create [name]",
+	Args:  cobra.MaximumNArgs(1),
+	Short: "Create a docker-container builder",
+	Long: "Create and start a buildx builder with the docker-container driver, which supports build caches and multi-platform builds.\n" +
+		"Select it for a project with the builder setting of the project's build configuration.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := defaultBuilderName
+		if len(args) > 0 {
+			name = args[0]
+		}
+		if builderCreateCmdArgs.keepStorage <= 0 {
+			return fmt.Errorf("--keep-storage must be positive")
+		}
+
+		if b, err := clients.InspectBuilder(name); err == nil {
+			return fmt.Errorf("builder %s already exists with driver %s", b.Name, b.Driver)
+		}
+
+		buildkitdConfig := filepath.Join(config.CacheDir(), "buildkitd-"+slugify(name)+".toml")
+		if err := os.WriteFile(buildkitdConfig, []byte(buildkitdToml(builderCreateCmdArgs.keepStorage)), 0644); err != nil {
+			return fmt.Errorf("error writing buildkitd config: %w", err)
+		}
+
+		if err := clients.CreateBuilder(name, buildkitdConfig); err != nil {
+			return err
+		}
+
+		logrus.Infof("Created builder %s, set builder = '%s' in the build configuration of a project to use it", name, name)
+		return nil
+	},
+}
+
+// buildkitdToml returns a buildkitd configuration that keeps up to
+// keepStorage GB of build cache and drops cache unused for a week first.
+func buildkitdToml(keepStorage int) string {
+	keepBytes := int64(keepStorage) << 30
+	return fmt.Sprintf(`[worker.oci]
+  gc = true
+
+[[worker.oci.gcpolicy]]
+  keepDuration = "168h"
+  keepBytes = %d
+
+[[worker.oci.gcpolicy]]
+  all = true
+  keepBytes = %d
+`, keepBytes, keepBytes)
+}
@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/zenginechris/devx/config"
+	"github.com/zenginechris/devx/internal/buildkit"
+	"github.com/zenginechris/devx/internal/clients"
+	"github.com/zenginechris/devx/internal/history"
+	"github.com/zenginechris/devx/internal/projects"
+)
+
+// defaultKeepBuildLogs is the number of build logs kept per project.
+const defaultKeepBuildLogs = 20
+
+// buildLogFile returns the log file of the build with id of project.
+func buildLogFile(project projects.Project, id string) string {
+	return filepath.Join(config.LogsDir(), slugify(project.Name), id+".log")
+}
+
+// timestampWriter prefixes every line written to w with the current time.
+type timestampWriter struct {
+	mu      sync.Mutex
+	w       io.Writer
+	midLine bool
+}
+
+func (t *timestampWriter) Write(b []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var buf bytes.Buffer
+	for rest := b; len(rest) > 0; {
+		if !t.midLine {
+			buf.WriteString(time.Now().Format("2006-01-02T15:04:05.000Z07:00 "))
+			t.midLine = true
+		}
+		i := bytes.IndexByte(rest, '\n')
+		if i < 0 {
+			buf.Write(rest)
+			break
+		}
+		buf.Write(rest[:i+1])
+		rest = rest[i+1:]
+		t.midLine = false
+	}
+
+	if _, err := t.w.Write(buf.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// logFileHook writes log entries to a build log.
+type logFileHook struct {
+	w         io.Writer
+	formatter logrus.Formatter
+}
+
+func (h *logFileHook) Levels() []logrus.Level { return logrus.AllLevels }
+
+func (h *logFileHook) Fire(entry *logrus.Entry) error {
+	b, err := h.formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+	_, err = h.w.Write(b)
+	return err
+}
+
+// startRecord starts the history record of the build and opens its log file,
+// which receives the devx logs and the builder output with timestamps.
+func (r *buildRun) startRecord() error {
+	r.record = history.Record{
+		ID:      history.NewID(),
+		Project: r.project.Name,
+		Start:   time.Now(),
+	}
+
+	file := buildLogFile(r.project, r.record.ID)
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return fmt.Errorf("error creating build log directory: %w", err)
+	}
+	f, err := os.Create(file)
+	if err != nil {
+		return fmt.Errorf("error creating build log: %w", err)
+	}
+	r.logFile = f
+	r.logOut = &timestampWriter{w: f}
+	r.record.LogFile = file
+
+	// keep the hooks to remove the log file hook when the build finishes
+	r.hooks = logrus.LevelHooks{}
+	for level, hooks := range logrus.StandardLogger().Hooks {
+		r.hooks[level] = slices.Clone(hooks)
+	}
+	logrus.AddHook(&logFileHook{w: r.logOut, formatter: &logrus.TextFormatter{DisableColors: true, DisableTimestamp: true}})
+	return nil
+}
+
+// finishRecord closes the build log and appends the record to the build history.
+func (r *buildRun) finishRecord(status string, err error) {
+	if r.logFile != nil {
+		if err != nil {
+			fmt.Fprintf(r.logOut, "build failed: %v\n", err)
+		}
+		logrus.StandardLogger().ReplaceHooks(r.hooks)
+		r.logFile.Close()
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Build log: %s\n", r.record.LogFile)
+		}
+		r.pruneBuildLogs()
+	}
+
+	r.record.End = time.Now()
+	r.record.Status = status
+	r.record.Image = r.image
+	if err != nil {
+		r.record.Error = err.Error()
+	}
+	if kubeContext, err := clients.CurrentContext(); err == nil {
+		r.record.KubeContext = kubeContext
+	}
+
+	if err := history.NewStore(config.HistoryFile()).Append(r.record); err != nil {
+		logrus.Warn(fmt.Sprintf("Could not save the build record: %v", err))
+	}
+}
+
+// pruneBuildLogs removes all but the newest build logs of the project,
+// keeping keep_build_logs of devx.toml or 20.
+func (r *buildRun) pruneBuildLogs() {
+	keep := defaultKeepBuildLogs
+	if cfg, err := config.Load(); err == nil && cfg.KeepBuildLogs > 0 {
+		keep = cfg.KeepBuildLogs
+	}
+
+	dir := filepath.Dir(r.record.LogFile)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	type logFile struct {
+		path    string
+		modTime time.Time
+	}
+	var logs []logFile
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil || !strings.HasSuffix(e.Name(), ".log") {
+			continue
+		}
+		logs = append(logs, logFile{filepath.Join(dir, e.Name()), info.ModTime()})
+	}
+	slices.SortFunc(logs, func(a, b logFile) int { return b.modTime.Compare(a.modTime) })
+
+	for i := keep; i < len(logs); i++ {
+		if err := os.Remove(logs[i].path); err != nil {
+			logrus.Debugf("Could not remove old build log: %v", err)
+		}
+	}
+}
+
+// buildFailure prints the failed step of the build and its last output lines
+// and returns the error of the build.
+func buildFailure(progress *buildkit.Progress, err error) error {
+	step, ok := progress.FailedStep()
+	if !ok {
+		if errs := progress.Errors(); len(errs) > 0 {
+			return fmt.Errorf("docker build failed: %s", errs[len(errs)-1])
+		}
+		return fmt.Errorf("docker build failed: %w", err)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "\nBuild failed at step %s\n", step.Name)
+	for _, line := range step.Output {
+		fmt.Fprintf(&sb, "  | %s\n", line)
+	}
+	fmt.Fprintf(&sb, "  %s\n\n", step.Error)
+	fmt.Fprint(os.Stderr, sb.String())
+
+	return fmt.Errorf("docker build failed at step %s: %s", step.Name, step.Error)
+}
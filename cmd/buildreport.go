@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/zenginechris/devx/config"
+	"github.com/zenginechris/devx/internal/buildkit"
+	"github.com/zenginechris/devx/internal/history"
+)
+
+// slowestSteps is the number of steps shown in the step summary.
+const slowestSteps = 5
+
+// printStepReport prints the cache hit ratio and the slowest steps of the
+// build, or with --report a comparison of all steps with the previous build.
+func (r *buildRun) printStepReport(steps []buildkit.StepTiming) {
+	if len(steps) == 0 {
+		return
+	}
+
+	var out io.Writer = os.Stdout
+	if r.logOut != nil {
+		out = io.MultiWriter(os.Stdout, r.logOut)
+	}
+
+	hits := buildkit.CacheHits(steps)
+	fmt.Fprintf(out, "\n%d steps, %d cached (%.0f%% cache hits)\n", len(steps), hits, 100*float64(hits)/float64(len(steps)))
+
+	if !buildProjectCmdArgs.report {
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+		for _, s := range buildkit.Slowest(steps, slowestSteps) {
+			if s.Cached || s.Duration == 0 {
+				continue
+			}
+			fmt.Fprintf(w, "%s\t  %s\n", s.Duration.Round(10*time.Millisecond), s.Name)
+		}
+		w.Flush()
+		return
+	}
+
+	previous, ok, err := history.NewStore(config.HistoryFile()).Previous(r.project.Name, r.record.Start, history.StatusSuccess)
+	if err != nil || !ok || len(previous.Steps) == 0 {
+		fmt.Fprintln(out, "No previous build with step timings to compare with")
+		previous = history.Record{}
+	} else {
+		fmt.Fprintf(out, "Compared with build %s of %s\n", previous.ID, previous.Start.Format(time.DateTime))
+	}
+
+	var regressions int
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STEP\tDURATION\tPREVIOUS\tCHANGE\t")
+	for _, c := range buildkit.Compare(steps, previous.Steps) {
+		prev, change, flag := "-", "new", ""
+		if c.Previous != nil {
+			prev = formatStepDuration(*c.Previous)
+			change = fmt.Sprintf("%+.2fs", c.Delta().Seconds())
+		}
+		if c.Regression() {
+			flag = "REGRESSION"
+			regressions++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", c.Name, formatStepDuration(c.StepTiming), prev, change, flag)
+	}
+	w.Flush()
+
+	if regressions > 0 {
+		r.log.Warnf("%d steps got slower by at least 50%% and one second", regressions)
+	}
+}
+
+func formatStepDuration(s buildkit.StepTiming) string {
+	if s.Cached {
+		return "cached"
+	}
+	return s.Duration.Round(10 * time.Millisecond).String()
+}
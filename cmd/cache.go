@@ -0,0 +1,413 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/zenginechris/devx/cmd/root"
+	"github.com/zenginechris/devx/config"
+	"github.com/zenginechris/devx/internal/buildcache"
+	"github.com/zenginechris/devx/internal/clients"
+	"github.com/zenginechris/devx/internal/filesystem"
+	"github.com/zenginechris/devx/internal/projects"
+)
+
+func init() {
+	cacheLsCmd.Flags().BoolVarP(&cacheLsCmdArgs.json, "json", "j", false, "print json output")
+	cacheCmd.AddCommand(cacheLsCmd)
+
+	cachePruneCmd.Flags().StringVar(&cachePruneCmdArgs.olderThan, "older-than", "", "only remove caches not used for this long, e.g. 7d or 12h")
+	cachePruneCmd.Flags().StringVar(&cachePruneCmdArgs.maxSize, "max-size", "", "remove the least recently used caches until the total size is below this, e.g. 10GB")
+	cacheCmd.AddCommand(cachePruneCmd)
+
+	cacheExportCmd.Flags().StringVarP(&cacheExportCmdArgs.output, "output", "o", "", "archive file, compressed with zstd for .zst and gzip for .gz (default <project>-buildcache.tar.zst)")
+	cacheCmd.AddCommand(cacheExportCmd)
+
+	cacheImportCmd.Flags().StringVarP(&cacheImportCmdArgs.project, "project", "p", "", "import the cache for this project instead of the exporting one")
+	cacheCmd.AddCommand(cacheImportCmd)
+
+	root.Cmd().AddCommand(cacheCmd)
+}
+
+// Kinds of project caches.
+const (
+	cacheKindBuild   = "build"
+	cacheKindStaging = "staging"
+)
+
+// projectCache is a cache directory of a project.
+type projectCache struct {
+	Project  string    `json:"project"`
+	Kind     string    `json:"kind"`
+	Dir      string    `json:"dir"`
+	Size     int64     `json:"size"`
+	LastUsed time.Time `json:"last_used"`
+}
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage build caches",
+	Long:  "Manage the build context staging directories and build caches of projects",
+}
+
+var cacheLsCmdArgs struct {
+	json bool
+}
+
+var cacheLsCmd = &cobra.Command{
+	Use:   "ls [project...]",
+	Short: "List caches",
+	Long:  "List the build caches and staging directories with their size and last use",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		caches, err := projectCaches(args)
+		if err != nil {
+			return err
+		}
+
+		if cacheLsCmdArgs.json {
+			if caches == nil {
+				caches = []projectCache{}
+			}
+			b, err := json.MarshalIndent(caches, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(b))
+			return nil
+		}
+
+		var total int64
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "PROJECT\tKIND\tSIZE\tLAST USED")
+		for _, c := range caches {
+			total += c.Size
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.Project, c.Kind, filesystem.FormatBytes(c.Size), c.LastUsed.Format(time.DateTime))
+		}
+		w.Flush()
+		fmt.Printf("\n%d caches, %s total\n", len(caches), filesystem.FormatBytes(total))
+
+		return nil
+	},
+}
+
+var cachePruneCmdArgs struct {
+	olderThan string
+	maxSize   string
+}
+
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune [project...]",
+	Short: "Remove caches",
+	Long: "Remove the build caches and staging directories of the given projects, or of all projects if none is given.\n" +
+		"With --older-than or --max-size only caches that are unused or exceed the size limit are removed.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		caches, err := projectCaches(args)
+		if err != nil {
+			return err
+		}
+
+		remove := caches
+		if cachePruneCmdArgs.olderThan != "" || cachePruneCmdArgs.maxSize != "" {
+			remove, err = cachesToPrune(caches, cachePruneCmdArgs.olderThan, cachePruneCmdArgs.maxSize)
+			if err != nil {
+				return err
+			}
+		}
+
+		var freed int64
+		for _, c := range remove {
+			if err := os.RemoveAll(c.Dir); err != nil {
+				return fmt.Errorf("error removing %s cache of %s: %w", c.Kind, c.Project, err)
+			}
+			freed += c.Size
+			logrus.Info(fmt.Sprintf("Removed %s cache of %s (%s)", c.Kind, c.Project, filesystem.FormatBytes(c.Size)))
+		}
+		logrus.Info(fmt.Sprintf("Removed %d caches, freed %s", len(remove), filesystem.FormatBytes(freed)))
+
+		return nil
+	},
+}
+
+var cacheExportCmdArgs struct {
+	output string
+}
+
+var cacheExportCmd = &cobra.Command{
+	Use:   "export <project>",
+	Args:  cobra.ExactArgs(1),
+	Short: "Export the build cache of a project",
+	Long:  "Export the local build cache of a project to an archive that can be imported on another machine",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		project, err := loadProject(args[0])
+		if err != nil {
+			return err
+		}
+
+		output := cacheExportCmdArgs.output
+		if output == "" {
+			output = slugify(project.Name) + "-buildcache.tar.zst"
+		}
+
+		meta := buildcache.Metadata{Project: project.Name, Created: time.Now().UTC()}
+		if b, err := clients.InspectBuilder(project.Build.Builder); err == nil {
+			meta.Builder, meta.Driver, meta.BuildKitVersion, meta.Platform = b.Name, b.Driver, b.BuildKitVersion, b.NativePlatform()
+		} else {
+			logrus.Warn(fmt.Sprintf("Exporting without builder metadata: %v", err))
+		}
+
+		w, err := buildcache.Create(output)
+		if err != nil {
+			return fmt.Errorf("error creating %s: %w", output, err)
+		}
+		meta, err = buildcache.Export(buildCacheDir(project), meta, w)
+		if closeErr := w.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(output)
+			return fmt.Errorf("error exporting build cache of %s: %w", project.Name, err)
+		}
+
+		logrus.Info(fmt.Sprintf("Exported %d files (%s) of the build cache of %s to %s",
+			len(meta.Files), filesystem.FormatBytes(meta.Size), project.Name, output))
+		return nil
+	},
+}
+
+var cacheImportCmdArgs struct {
+	project string
+}
+
+var cacheImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Args:  cobra.ExactArgs(1),
+	Short: "Import a build cache",
+	Long: "Import a build cache archive created by devx cache export, replacing the local build cache of the project.\n" +
+		"Every file is verified against the hashes recorded on export.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file := args[0]
+
+		meta, err := readCacheMetadata(file)
+		if err != nil {
+			return err
+		}
+
+		name := meta.Project
+		if cacheImportCmdArgs.project != "" {
+			name = cacheImportCmdArgs.project
+		}
+		project, err := loadProject(name)
+		if err != nil {
+			return err
+		}
+		checkCacheCompatibility(project, meta)
+
+		dir := buildCacheDir(project)
+		importDir, err := newCacheDir(project)
+		if err != nil {
+			return err
+		}
+
+		r, err := buildcache.Open(file)
+		if err != nil {
+			os.RemoveAll(importDir)
+			return err
+		}
+		_, err = buildcache.Import(r, importDir)
+		if closeErr := r.Close(); err == nil && closeErr != nil {
+			os.RemoveAll(importDir)
+			err = closeErr
+		}
+		if err != nil {
+			return fmt.Errorf("error importing %s: %w", file, err)
+		}
+
+		if err := replaceCacheDir(importDir, dir); err != nil {
+			return err
+		}
+
+		logrus.Info(fmt.Sprintf("Imported %d files (%s) into the build cache of %s",
+			len(meta.Files), filesystem.FormatBytes(meta.Size), project.Name))
+		return nil
+	},
+}
+
+// readCacheMetadata reads the metadata of the cache archive file.
+func readCacheMetadata(file string) (buildcache.Metadata, error) {
+	r, err := buildcache.Open(file)
+	if err != nil {
+		return buildcache.Metadata{}, err
+	}
+	defer r.Close()
+
+	meta, err := buildcache.ReadMetadata(r)
+	if err != nil {
+		return meta, fmt.Errorf("error reading %s: %w", file, err)
+	}
+	return meta, nil
+}
+
+// checkCacheCompatibility warns if the builder of project differs from the
+// builder that exported the cache, which makes cache hits unlikely.
+func checkCacheCompatibility(project projects.Project, meta buildcache.Metadata) {
+	b, err := clients.InspectBuilder(project.Build.Builder)
+	if err != nil {
+		logrus.Warn(fmt.Sprintf("Could not inspect the builder to check the cache compatibility: %v", err))
+		return
+	}
+
+	if b.Driver == clients.DriverDocker {
+		logrus.Warn(fmt.Sprintf("Builder %s uses the docker driver, which does not use the local build cache", b.Name))
+	}
+	if meta.Platform != "" && b.NativePlatform() != "" && meta.Platform != b.NativePlatform() {
+		logrus.Warn(fmt.Sprintf("The cache was exported on %s, this builder runs on %s, few layers will be reused",
+			meta.Platform, b.NativePlatform()))
+	}
+	if meta.BuildKitVersion != "" && b.BuildKitVersion != "" && meta.BuildKitVersion != b.BuildKitVersion {
+		logrus.Warn(fmt.Sprintf("The cache was exported with BuildKit %s, this builder runs BuildKit %s",
+			meta.BuildKitVersion, b.BuildKitVersion))
+	}
+}
+
+// cachesToPrune returns the caches unused for longer than olderThan and the
+// least recently used caches that exceed maxSize in total.
+func cachesToPrune(caches []projectCache, olderThan, maxSize string) ([]projectCache, error) {
+	var remove, keep []projectCache
+
+	if olderThan != "" {
+		age, err := parseAge(olderThan)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range caches {
+			if time.Since(c.LastUsed) > age {
+				remove = append(remove, c)
+			} else {
+				keep = append(keep, c)
+			}
+		}
+	} else {
+		keep = caches
+	}
+
+	if maxSize != "" {
+		limit, err := filesystem.ParseBytes(maxSize)
+		if err != nil {
+			return nil, err
+		}
+
+		var total int64
+		for _, c := range keep {
+			total += c.Size
+		}
+
+		slices.SortFunc(keep, func(a, b projectCache) int { return a.LastUsed.Compare(b.LastUsed) })
+		for _, c := range keep {
+			if total <= limit {
+				break
+			}
+			remove = append(remove, c)
+			total -= c.Size
+		}
+	}
+
+	return remove, nil
+}
+
+// parseAge parses a duration that may be given in days, e.g. 7d.
+func parseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid duration '%s'", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration '%s'", s)
+	}
+	return d, nil
+}
+
+// projectCaches returns the build caches and staging directories of the named
+// projects, or of all projects if no names are given.
+func projectCaches(names []string) ([]projectCache, error) {
+	var slugs []string
+	for _, name := range names {
+		slugs = append(slugs, slugify(name))
+	}
+
+	var caches []projectCache
+	for _, kind := range []struct{ name, dir string }{
+		{cacheKindBuild, config.BuildCacheDir()},
+		{cacheKindStaging, config.StagingDir()},
+	} {
+		entries, err := os.ReadDir(kind.dir)
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s cache directory: %w", kind.name, err)
+		}
+
+		for _, e := range entries {
+			// skip directories of builds and imports that are still writing a cache
+			if !e.IsDir() || strings.Contains(e.Name(), newCacheDirSuffix) {
+				continue
+			}
+			if len(slugs) > 0 && !slices.Contains(slugs, e.Name()) {
+				continue
+			}
+
+			info, err := e.Info()
+			if err != nil {
+				return nil, err
+			}
+			dir := filepath.Join(kind.dir, e.Name())
+			size, err := dirSize(dir)
+			if err != nil {
+				logrus.Warn(fmt.Sprintf("Could not determine size of %s: %v", dir, err))
+			}
+
+			caches = append(caches, projectCache{
+				Project:  e.Name(),
+				Kind:     kind.name,
+				Dir:      dir,
+				Size:     size,
+				LastUsed: info.ModTime(),
+			})
+		}
+	}
+
+	slices.SortFunc(caches, func(a, b projectCache) int {
+		if c := strings.Compare(a.Project, b.Project); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Kind, b.Kind)
+	})
+
+	return caches, nil
+}
+
+// dirSize returns the total size of all regular files below dir.
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
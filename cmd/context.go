@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/zenginechris/devx/cmd/root"
+	"github.com/zenginechris/devx/internal/filesystem"
+)
+
+func init() {
+	contextLsCmd.Flags().BoolVarP(&contextLsCmdArgs.ignored, "ignored", "i", false, "list ignored paths and the rule that excluded them")
+	setProjectContextCmd.AddCommand(contextLsCmd)
+
+	contextInspectCmd.Flags().BoolVarP(&contextInspectCmdArgs.json, "json", "j", false, "print json output")
+	contextInspectCmd.Flags().IntVarP(&contextInspectCmdArgs.top, "top", "n", 10, "number of largest files and directories to show")
+	setProjectContextCmd.AddCommand(contextInspectCmd)
+
+	diffContextCmd.Flags().BoolVarP(&diffContextCmdArgs.json, "json", "j", false, "print json output")
+	root.Cmd().AddCommand(diffContextCmd)
+}
+
+// contextSubcommand reports whether name is a subcommand of devx context,
+// which shadows a project of that name in devx context <project>.
+func contextSubcommand(name string) bool {
+	for _, c := range setProjectContextCmd.Commands() {
+		if c.Name() == name || c.HasAlias(name) {
+			return true
+		}
+	}
+	return false
+}
+
+var contextLsCmdArgs struct {
+	ignored bool
+}
+
+var contextLsCmd = &cobra.Command{
+	Use:     "ls <project>",
+	Aliases: []string{"list"},
+	Args:    cobra.ExactArgs(1),
+	Short:   "List the files of a build context",
+	Long:    "List exactly which files would be sent to the builder for a project, after applying all ignore rules",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		project, err := loadProject(args[0])
+		if err != nil {
+			return err
+		}
+
+		assembler := filesystem.NewContextAssembler(contextSources(project)...)
+
+		if contextLsCmdArgs.ignored {
+			ignored, err := assembler.Ignored()
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 4, 8, 4, ' ', 0)
+			_, _ = fmt.Fprintln(w, "PATH\tRULE")
+			for _, i := range ignored {
+				name := i.Name
+				if i.Dir {
+					name += "/"
+				}
+				_, _ = fmt.Fprintf(w, "%s\t%s\n", name, i.Rule)
+			}
+			return w.Flush()
+		}
+
+		entries, err := assembler.Entries()
+		if err != nil {
+			return err
+		}
+
+		for _, e := range entries {
+			name := e.Name
+			if e.IsDir() {
+				name += "/"
+			}
+			_, _ = fmt.Fprintln(cmd.OutOrStdout(), name)
+		}
+		return nil
+	},
+}
+
+var contextInspectCmdArgs struct {
+	json bool
+	top  int
+}
+
+var contextInspectCmd = &cobra.Command{
+	Use:   "inspect <project>",
+	Args:  cobra.ExactArgs(1),
+	Short: "Report the size and content of a build context",
+	Long: "Assemble the build context of a project without building and report its size, the largest files and directories, " +
+		"the paths excluded by each ignore rule and suspicious content like .git, node_modules, .env files and private keys",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		project, err := loadProject(args[0])
+		if err != nil {
+			return err
+		}
+
+		entries, ignored, err := filesystem.NewContextAssembler(contextSources(project)...).Assemble()
+		if err != nil {
+			return err
+		}
+
+		report := filesystem.Inspect(entries, ignored, contextInspectCmdArgs.top)
+
+		if contextInspectCmdArgs.json {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(report)
+		}
+
+		out := cmd.OutOrStdout()
+		w := tabwriter.NewWriter(out, 4, 8, 4, ' ', 0)
+		_, _ = fmt.Fprintf(w, "TOTAL SIZE\t%s\n", filesystem.FormatBytes(report.TotalSize))
+		_, _ = fmt.Fprintf(w, "FILES\t%d\n", report.Files)
+		_, _ = fmt.Fprintf(w, "DIRECTORIES\t%d\n", report.Dirs)
+		_, _ = fmt.Fprintf(w, "SYMLINKS\t%d\n", report.Symlinks)
+
+		_, _ = fmt.Fprintln(w, "\nLARGEST FILES\tSIZE")
+		for _, e := range report.LargestFiles {
+			_, _ = fmt.Fprintf(w, "%s\t%s\n", e.Name, filesystem.FormatBytes(e.Size))
+		}
+
+		_, _ = fmt.Fprintln(w, "\nLARGEST DIRECTORIES\tSIZE")
+		for _, e := range report.LargestDirs {
+			_, _ = fmt.Fprintf(w, "%s/\t%s\n", e.Name, filesystem.FormatBytes(e.Size))
+		}
+
+		_, _ = fmt.Fprintln(w, "\nRULE\tIGNORED")
+		for _, m := range report.IgnoredBy {
+			_, _ = fmt.Fprintf(w, "%s\t%s\n", m.Rule, strings.Join(m.Paths, ", "))
+		}
+
+		if len(report.Warnings) > 0 {
+			_, _ = fmt.Fprintln(w, "\nWARNING\tPATH")
+			for _, warning := range report.Warnings {
+				_, _ = fmt.Fprintf(w, "%s\t%s\n", warning.Message, warning.Name)
+			}
+		}
+
+		return w.Flush()
+	},
+}
+
+var diffContextCmdArgs struct {
+	json bool
+}
+
+var diffContextCmd = &cobra.Command{
+	Use:   "diff-context <project>",
+	Args:  cobra.ExactArgs(1),
+	Short: "Show context changes since the last build",
+	Long:  "Show the files of the build context that were added, modified or removed since the last successful build",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		project, err := loadProject(args[0])
+		if err != nil {
+			return err
+		}
+
+		previous, err := filesystem.LoadManifest(manifestFile(project))
+		if os.IsNotExist(err) {
+			return fmt.Errorf("no previous build of %s found", project.Name)
+		}
+		if err != nil {
+			return err
+		}
+
+		entries, err := filesystem.NewContextAssembler(contextSources(project)...).Entries()
+		if err != nil {
+			return err
+		}
+		current, err := filesystem.ManifestOf(entries, previous)
+		if err != nil {
+			return err
+		}
+
+		diff := current.Diff(previous)
+
+		if diffContextCmdArgs.json {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(diff)
+		}
+
+		if diff.Empty() {
+			_, _ = fmt.Fprintln(cmd.OutOrStdout(), "No changes since the last build")
+			return nil
+		}
+
+		for _, c := range []struct {
+			prefix string
+			names  []string
+		}{{"A", diff.Added}, {"M", diff.Modified}, {"D", diff.Removed}} {
+			for _, name := range c.names {
+				_, _ = fmt.Fprintf(cmd.OutOrStdout(), "%s %s\n", c.prefix, name)
+			}
+		}
+		return nil
+	},
+}
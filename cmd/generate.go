@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/zenginechris/devx/internal/filesystem"
+	"github.com/zenginechris/devx/internal/projects"
+)
+
+// generateFiles renders the generated files of the project into a temporary
+// directory and adds them to the build context. They are not part of the
+// context digest, as they may contain the image tag derived from it.
+func (r *buildRun) generateFiles() error {
+	dir, err := os.MkdirTemp("", "devx-generated-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	r.generatedDir = dir
+
+	vars := r.vars()
+	sources := contextSources(r.project)
+	for i, f := range r.project.Generate {
+		if f.Dest == "" {
+			return fmt.Errorf("generated file %s has no dest", f.Template)
+		}
+		tmpl, err := os.ReadFile(f.TemplatePath(r.project))
+		if err != nil {
+			return fmt.Errorf("error reading template of %s: %w", f.Dest, err)
+		}
+
+		file := filepath.Join(dir, strconv.Itoa(i), path.Base(f.Dest))
+		if err := writeFileFromTemplate(string(tmpl), vars, file); err != nil {
+			return fmt.Errorf("error generating %s: %w", f.Dest, err)
+		}
+		sources = append(sources, filesystem.ContextSource{Path: file, Dest: f.Dest})
+		r.log.Debugf("Generated %s from %s", f.Dest, f.Template)
+	}
+
+	// assemble again to fail on collisions before the build starts
+	r.assembler = filesystem.NewContextAssembler(sources...)
+	entries, _, err := r.assembler.Assemble()
+	if err != nil {
+		return err
+	}
+	r.entries = entries
+	return nil
+}
+
+// removeGeneratedFiles removes the files rendered by generateFiles.
+func (r *buildRun) removeGeneratedFiles() {
+	if r.generatedDir == "" {
+		return
+	}
+	if err := os.RemoveAll(r.generatedDir); err != nil {
+		r.log.Debugf("Could not remove generated files: %v", err)
+	}
+}
+
+// withGeneratedFiles returns entries with the generated files and their parent
+// directories, for checks that run before the files are rendered.
+func withGeneratedFiles(entries []filesystem.Entry, generated []projects.GeneratedFile) []filesystem.Entry {
+	added := map[string]bool{}
+	for _, f := range generated {
+		for name := path.Clean(strings.TrimPrefix(f.Dest, "/")); name != "." && name != "/"; name = path.Dir(name) {
+			if !filesystem.HasEntry(entries, name) {
+				added[name] = true
+			}
+		}
+	}
+	if len(added) == 0 {
+		return entries
+	}
+
+	entries = slices.Clone(entries)
+	for name := range added {
+		entries = append(entries, filesystem.Entry{Name: name})
+	}
+	slices.SortFunc(entries, func(a, b filesystem.Entry) int { return strings.Compare(a.Name, b.Name) })
+	return entries
+}
@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/zenginechris/devx/cmd/root"
+	"github.com/zenginechris/devx/config"
+	"github.com/zenginechris/devx/internal/history"
+)
+
+func init() {
+	historyCmd.Flags().BoolVarP(&historyCmdArgs.json, "json", "j", false, "print json output")
+	historyCmd.Flags().StringVar(&historyCmdArgs.status, "status", "", "only show builds with this status: success, failed or skipped")
+	historyCmd.Flags().StringVar(&historyCmdArgs.since, "since", "", "only show builds started within this duration, e.g. 7d or 12h")
+	historyCmd.Flags().IntVarP(&historyCmdArgs.limit, "limit", "n", 20, "maximum number of builds to show, 0 shows all")
+
+	historyShowCmd.Flags().BoolVarP(&historyShowCmdArgs.json, "json", "j", false, "print the record as json instead of replaying the log")
+	historyCmd.AddCommand(historyShowCmd)
+
+	root.Cmd().AddCommand(historyCmd)
+}
+
+var historyCmdArgs struct {
+	json   bool
+	status string
+	since  string
+	limit  int
+}
+
+var historyCmd = &cobra.Command{
+	Use:   "history [project]",
+	Args:  cobra.MaximumNArgs(1),
+	Short: "List past builds",
+	Long:  "List past builds, newest first, with their duration, outcome and image",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		records, err := history.NewStore(config.HistoryFile()).Records()
+		if err != nil {
+			return err
+		}
+
+		var since time.Time
+		if historyCmdArgs.since != "" {
+			age, err := parseAge(historyCmdArgs.since)
+			if err != nil {
+				return err
+			}
+			since = time.Now().Add(-age)
+		}
+
+		filtered := []history.Record{}
+		for _, r := range slices.Backward(records) {
+			if len(args) > 0 && slugify(r.Project) != slugify(args[0]) {
+				continue
+			}
+			if historyCmdArgs.status != "" && r.Status != historyCmdArgs.status {
+				continue
+			}
+			if r.Start.Before(since) {
+				continue
+			}
+			filtered = append(filtered, r)
+			if historyCmdArgs.limit > 0 && len(filtered) == historyCmdArgs.limit {
+				break
+			}
+		}
+
+		if historyCmdArgs.json {
+			b, err := json.MarshalIndent(filtered, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(b))
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tPROJECT\tSTARTED\tDURATION\tSTATUS\tIMAGE\tKUBE CONTEXT")
+		for _, r := range filtered {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", r.ID, r.Project, r.Start.Format(time.DateTime),
+				r.Duration().Round(time.Millisecond), r.Status, r.Image, r.KubeContext)
+		}
+		return w.Flush()
+	},
+}
+
+var historyShowCmdArgs struct {
+	json bool
+}
+
+var historyShowCmd = &cobra.Command{
+	Use:   "show <id>",
+	Args:  cobra.ExactArgs(1),
+	Short: "Show a past build",
+	Long:  "Show the stages of a past build and replay its build log. The id may be abbreviated.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		r, err := history.NewStore(config.HistoryFile()).Find(args[0])
+		if err != nil {
+			return err
+		}
+
+		if historyShowCmdArgs.json {
+			b, err := json.MarshalIndent(r, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(b))
+			return nil
+		}
+
+		fmt.Printf("Build %s of %s, %s\n", r.ID, r.Project, r.Status)
+		fmt.Printf("Started %s, took %s\n", r.Start.Format(time.DateTime), r.Duration().Round(time.Millisecond))
+		if r.Image != "" {
+			fmt.Printf("Image %s\n", r.Image)
+		}
+		if r.KubeContext != "" {
+			fmt.Printf("Kube context %s\n", r.KubeContext)
+		}
+		if r.Error != "" {
+			fmt.Printf("Error: %s\n", r.Error)
+		}
+
+		if len(r.Stages) > 0 {
+			fmt.Println()
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "STAGE\tDURATION")
+			for _, s := range r.Stages {
+				failed := ""
+				if s.Failed {
+					failed = " (failed)"
+				}
+				fmt.Fprintf(w, "%s\t%s%s\n", s.Stage, s.Duration.Round(time.Millisecond), failed)
+			}
+			w.Flush()
+		}
+
+		if len(r.Steps) > 0 {
+			fmt.Println()
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "STEP\tDURATION")
+			for _, s := range r.Steps {
+				fmt.Fprintf(w, "%s\t%s\n", s.Name, formatStepDuration(s))
+			}
+			w.Flush()
+		}
+
+		if r.LogFile == "" {
+			return nil
+		}
+		f, err := os.Open(r.LogFile)
+		if err != nil {
+			return fmt.Errorf("build log is not available: %w", err)
+		}
+		defer f.Close()
+
+		fmt.Printf("\nLog %s:\n", r.LogFile)
+		_, err = io.Copy(os.Stdout, f)
+		return err
+	},
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/zenginechris/devx/cli"
+	"github.com/zenginechris/devx/internal/projects"
+)
+
+// addHooks adds a stage for each hook to chain. env is added to the
+// environment of the hooks.
+func (r *buildRun) addHooks(chain *cli.ActiveCommandChain, name string, hooks []projects.Hook, env ...string) {
+	for _, hook := range hooks {
+		chain.Stagef("running %s hook: %s", name, hook.Command)
+		chain.Add(func() error {
+			return r.runHook(hook, env...)
+		})
+	}
+}
+
+// runHook runs the command of hook with sh in the working directory of the hook.
+func (r *buildRun) runHook(hook projects.Hook, env ...string) error {
+	if strings.TrimSpace(hook.Command) == "" {
+		return fmt.Errorf("hook without a command")
+	}
+
+	c := cli.Command("sh", "-c", hook.Command)
+	c.Dir = hook.WorkDir(r.project)
+	c.Env = append(os.Environ(), r.vars().Env()...)
+	c.Env = append(c.Env, env...)
+	for _, k := range projects.SortedKeys(hook.Env) {
+		c.Env = append(c.Env, k+"="+hook.Env[k])
+	}
+	if r.logOut != nil {
+		c.Stdout = io.MultiWriter(os.Stdout, r.logOut)
+		c.Stderr = io.MultiWriter(os.Stderr, r.logOut)
+	}
+
+	if err := c.Run(); err != nil {
+		err = fmt.Errorf("hook failed: %w", err)
+		if hook.NonFatal {
+			return cli.ErrNonFatal(err)
+		}
+		return err
+	}
+	return nil
+}
+
+// runFailureHooks runs the on_failure hooks of the project after the build failed with err.
+func (r *buildRun) runFailureHooks(ctx context.Context, err error) {
+	if len(r.project.Hooks.OnFailure) == 0 {
+		return
+	}
+
+	chain := cli.New("build").Init(ctx)
+	r.addHooks(chain, "on_failure", r.project.Hooks.OnFailure, "DEVX_ERROR="+err.Error())
+	if err := chain.Exec(); err != nil {
+		chain.Logger().Warn(err)
+	}
+	r.record.Stages = append(r.record.Stages, chain.Timings()...)
+}
+
+// vars returns the variables of the build known so far.
+func (r *buildRun) vars() projects.BuildVars {
+	v := projects.BuildVars{
+		Project:    r.project.Name,
+		Namespace:  r.project.Namespace,
+		Deployment: r.project.DeploymentName,
+		Context:    r.project.Context,
+		Image:      r.image,
+		Digest:     r.digest,
+		BuildID:    r.record.ID,
+		BuildTime:  r.record.Start.Format(time.RFC3339),
+	}
+	if _, tag, ok := strings.Cut(r.image, ":"); ok {
+		v.ImageTag = tag
+	}
+	if r.git != nil {
+		v.Commit, v.Branch, v.Dirty = r.git.Commit, r.git.Branch, r.git.Dirty
+	}
+	return v
+}
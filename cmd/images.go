@@ -0,0 +1,214 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/zenginechris/devx/cmd/root"
+	"github.com/zenginechris/devx/config"
+	"github.com/zenginechris/devx/internal/clients"
+)
+
+// defaultKeepImages is the number of images per project kept by devx images prune.
+const defaultKeepImages = 3
+
+func init() {
+	imagesLsCmd.Flags().BoolVarP(&imagesLsCmdArgs.json, "json", "j", false, "print json output")
+	imagesCmd.AddCommand(imagesLsCmd)
+
+	imagesPruneCmd.Flags().IntVar(&imagesPruneCmdArgs.keep, "keep", 0, fmt.Sprintf("number of newest images kept per project (if unset, keep_images of devx.toml or %d)", defaultKeepImages))
+	imagesPruneCmd.Flags().BoolVar(&imagesPruneCmdArgs.dryRun, "dry-run", false, "only print the images that would be removed")
+	imagesCmd.AddCommand(imagesPruneCmd)
+
+	root.Cmd().AddCommand(imagesCmd)
+}
+
+var imagesCmd = &cobra.Command{
+	Use:   "images",
+	Short: "Manage built images",
+	Long:  "Manage the images built by devx in the local docker image store",
+}
+
+var imagesLsCmdArgs struct {
+	json bool
+}
+
+// listedImage is an image as listed by devx images ls.
+type listedImage struct {
+	clients.Image
+	Project string `json:"project"`
+	InUse   *bool  `json:"in_use,omitempty"`
+}
+
+var imagesLsCmd = &cobra.Command{
+	Use:   "ls [project]",
+	Args:  cobra.MaximumNArgs(1),
+	Short: "List built images",
+	Long:  "List the images built by devx, newest first, and whether the cluster uses them",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		slug := ""
+		if len(args) > 0 {
+			slug = slugify(args[0])
+		}
+
+		images, err := clients.ListImages(imageRepository(slug))
+		if err != nil {
+			return err
+		}
+
+		inUse, err := clients.ClusterImages()
+		if err != nil {
+			logrus.Warn(fmt.Sprintf("Could not determine the images used in the cluster: %v", err))
+		}
+
+		listed := make([]listedImage, 0, len(images))
+		for _, img := range images {
+			l := listedImage{Image: img, Project: strings.TrimPrefix(img.Repository, "devx_")}
+			if inUse != nil {
+				used := inUse[img.Ref()]
+				l.InUse = &used
+			}
+			listed = append(listed, l)
+		}
+
+		if imagesLsCmdArgs.json {
+			b, err := json.MarshalIndent(listed, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(b))
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "PROJECT\tIMAGE\tCREATED\tSIZE\tIN USE")
+		for _, l := range listed {
+			used := "-"
+			if l.InUse != nil && *l.InUse {
+				used = "yes"
+			} else if l.InUse != nil {
+				used = "no"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", l.Project, l.Ref(), l.Created.Format(time.DateTime), l.Size, used)
+		}
+		return w.Flush()
+	},
+}
+
+var imagesPruneCmdArgs struct {
+	keep   int
+	dryRun bool
+}
+
+var imagesPruneCmd = &cobra.Command{
+	Use:   "prune [project...]",
+	Short: "Remove old images",
+	Long: "Remove all but the newest images of the given projects, or of all projects if none is given.\n" +
+		"Images referenced by a workload in the cluster are never removed.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if imagesPruneCmdArgs.keep < 0 {
+			return fmt.Errorf("--keep must not be negative, got %d", imagesPruneCmdArgs.keep)
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+
+		keep := func(slug string) int {
+			if cmd.Flags().Changed("keep") {
+				return imagesPruneCmdArgs.keep
+			}
+			for _, p := range cfg.Projects {
+				if slugify(p.Name) == slug && cfg.KeepImagesFor(p) > 0 {
+					return cfg.KeepImagesFor(p)
+				}
+			}
+			if cfg.KeepImages > 0 {
+				return cfg.KeepImages
+			}
+			return defaultKeepImages
+		}
+
+		var slugs []string
+		for _, name := range args {
+			slugs = append(slugs, slugify(name))
+		}
+		if len(slugs) == 0 {
+			images, err := clients.ListImages(imageRepository(""))
+			if err != nil {
+				return err
+			}
+			for _, img := range images {
+				slug := strings.TrimPrefix(img.Repository, "devx_")
+				if !slices.Contains(slugs, slug) {
+					slugs = append(slugs, slug)
+				}
+			}
+		}
+
+		inUse, err := clients.ClusterImages()
+		if err != nil {
+			return fmt.Errorf("cannot determine the images used in the cluster, not removing any: %w", err)
+		}
+
+		var removed int
+		for _, slug := range slugs {
+			n, err := pruneImages(logrus.NewEntry(logrus.StandardLogger()), slug, keep(slug), inUse, imagesPruneCmdArgs.dryRun)
+			removed += n
+			if err != nil {
+				return err
+			}
+		}
+		if imagesPruneCmdArgs.dryRun {
+			logrus.Info(fmt.Sprintf("Would remove %d images", removed))
+		} else {
+			logrus.Info(fmt.Sprintf("Removed %d images", removed))
+		}
+
+		return nil
+	},
+}
+
+// imageRepository returns the repository of the images of the project with
+// slug, or a pattern matching the images of all projects if slug is empty.
+func imageRepository(slug string) string {
+	if slug == "" {
+		return "devx_*"
+	}
+	return "devx_" + slug
+}
+
+// pruneImages removes all but the newest keep images of the project with slug.
+// Images in inUse are never removed.
+func pruneImages(log *logrus.Entry, slug string, keep int, inUse map[string]bool, dryRun bool) (int, error) {
+	images, err := clients.ListImages(imageRepository(slug))
+	if err != nil {
+		return 0, err
+	}
+
+	var removed int
+	for i, img := range images {
+		if i < keep || inUse[img.Ref()] {
+			continue
+		}
+		if dryRun {
+			log.Infof("Would remove %s (%s)", img.Ref(), img.Size)
+			removed++
+			continue
+		}
+		if err := clients.RemoveImage(img.Ref()); err != nil {
+			return removed, err
+		}
+		log.Infof("Removed %s (%s)", img.Ref(), img.Size)
+		removed++
+	}
+	return removed, nil
+}
@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/zenginechris/devx/cli"
+	"github.com/zenginechris/devx/config"
+	"github.com/zenginechris/devx/internal/clients"
+	"github.com/zenginechris/devx/internal/filesystem"
+	"github.com/zenginechris/devx/internal/history"
+)
+
+func init() {
+	imagesCmd.AddCommand(imagesSizeCmd)
+}
+
+var imagesSizeCmd = &cobra.Command{
+	Use:   "size <project>",
+	Args:  cobra.ExactArgs(1),
+	Short: "Show the image size of the last build",
+	Long:  "Show the layers of the image of the last build of a project with their size and the change against the build before",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		project, err := loadProject(args[0])
+		if err != nil {
+			return err
+		}
+
+		store := history.NewStore(config.HistoryFile())
+		latest, ok, err := previousImageSize(store, history.Record{Project: project.Name, Start: time.Now()})
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("no build of %s with a recorded image size", project.Name)
+		}
+		previous, hasPrevious, err := previousImageSize(store, latest)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Image %s of build %s, %s\n", latest.Image, latest.ID, filesystem.FormatBytes(latest.ImageSize))
+		if hasPrevious {
+			fmt.Printf("%s against build %s (%s)\n", formatSizeDelta(latest.ImageSize-previous.ImageSize),
+				previous.ID, filesystem.FormatBytes(previous.ImageSize))
+		}
+		fmt.Println()
+
+		// layers match the previous build by their instruction
+		previousLayers := map[string]int64{}
+		for _, l := range previous.Layers {
+			previousLayers[l.CreatedBy] += l.Size
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "SIZE\tCHANGE\tCREATED BY")
+		for _, l := range latest.Layers {
+			change := "-"
+			if prev, ok := previousLayers[l.CreatedBy]; ok && hasPrevious {
+				change = formatSizeDelta(l.Size - prev)
+			} else if hasPrevious {
+				change = "new"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", filesystem.FormatBytes(l.Size), change, layerInstruction(l.CreatedBy))
+		}
+		return w.Flush()
+	},
+}
+
+// analyzeImage records the layer sizes of the built image and checks them
+// against the maximum image size of the project.
+func (r *buildRun) analyzeImage() error {
+	if r.builder.Driver != clients.DriverDocker && strings.Contains(r.platforms, ",") {
+		r.log.Debug("Multi-platform images are not loaded into the docker image store, skipping the size analysis")
+		return nil
+	}
+
+	layers, err := clients.ImageLayers(r.image)
+	if err != nil {
+		return cli.ErrNonFatal(err)
+	}
+	var size int64
+	r.record.Layers = nil
+	for _, l := range layers {
+		size += l.Size
+		r.record.Layers = append(r.record.Layers, history.Layer{CreatedBy: l.CreatedBy, Size: l.Size})
+	}
+	r.record.ImageSize = size
+
+	msg := fmt.Sprintf("Image size %s", filesystem.FormatBytes(size))
+	store := history.NewStore(config.HistoryFile())
+	if previous, ok, err := previousImageSize(store, r.record); err == nil && ok {
+		msg += fmt.Sprintf(", %s against build %s", formatSizeDelta(size-previous.ImageSize), previous.ID)
+	}
+	r.log.Info(msg)
+
+	if r.project.MaxImageSize == "" {
+		return nil
+	}
+	limit, err := filesystem.ParseBytes(r.project.MaxImageSize)
+	if err != nil {
+		return fmt.Errorf("invalid max_image_size: %w", err)
+	}
+	if size <= limit {
+		return nil
+	}
+
+	err = fmt.Errorf("image size %s exceeds max_image_size %s", filesystem.FormatBytes(size), r.project.MaxImageSize)
+	switch r.project.MaxImageSizeAction {
+	case "fail":
+		return err
+	case "", "warn":
+		return cli.ErrNonFatal(err)
+	default:
+		return fmt.Errorf("invalid max_image_size_action '%s', expected warn or fail", r.project.MaxImageSizeAction)
+	}
+}
+
+// previousImageSize returns the latest build before r with a recorded image size.
+func previousImageSize(store *history.Store, r history.Record) (history.Record, bool, error) {
+	records, err := store.Records()
+	if err != nil {
+		return history.Record{}, false, err
+	}
+	for _, previous := range slices.Backward(records) {
+		if previous.Project == r.Project && previous.Start.Before(r.Start) && previous.ImageSize > 0 {
+			return previous, true, nil
+		}
+	}
+	return history.Record{}, false, nil
+}
+
+// layerInstruction shortens the instruction that created a layer.
+func layerInstruction(createdBy string) string {
+	s := strings.TrimPrefix(createdBy, "/bin/sh -c #(nop) ")
+	s = strings.TrimSpace(strings.TrimSuffix(s, " # buildkit"))
+	if len(s) > 100 {
+		s = s[:97] + "..."
+	}
+	return s
+}
+
+func formatSizeDelta(delta int64) string {
+	if delta < 0 {
+		return "-" + filesystem.FormatBytes(-delta)
+	}
+	return "+" + filesystem.FormatBytes(delta)
+}
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/zenginechris/devx/cmd/root"
+	"github.com/zenginechris/devx/internal/dockerfile"
+	"github.com/zenginechris/devx/internal/filesystem"
+	"github.com/zenginechris/devx/internal/projects"
+)
+
+func init() {
+	lintCmd.Flags().BoolVarP(&lintCmdArgs.json, "json", "j", false, "print json output")
+	root.Cmd().AddCommand(lintCmd)
+}
+
+var lintCmdArgs struct {
+	json bool
+}
+
+var lintCmd = &cobra.Command{
+	Use:   "lint <project>",
+	Args:  cobra.ExactArgs(1),
+	Short: "Check the Dockerfile of a project",
+	Long: "Check the Dockerfile of a project against its assembled build context and build args for COPY/ADD sources " +
+		"missing from the context, base images without a pinned version, images running as root, apt installs without " +
+		"cleanup and ARGs that are used but never passed. Rules are disabled with lint.disable in the project configuration.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		project, err := loadProject(args[0])
+		if err != nil {
+			return err
+		}
+
+		entries, ignored, err := filesystem.NewContextAssembler(contextSources(project)...).Assemble()
+		if err != nil {
+			return err
+		}
+		findings, err := lintDockerfile(project, entries, ignored)
+		if err != nil {
+			return err
+		}
+
+		if lintCmdArgs.json {
+			if findings == nil {
+				findings = []dockerfile.Finding{}
+			}
+			b, err := json.MarshalIndent(findings, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(b))
+		} else if len(findings) == 0 {
+			fmt.Println("No problems found")
+		} else {
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "LINE\tSEVERITY\tRULE\tMESSAGE")
+			for _, f := range findings {
+				fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", f.Line, f.Severity, f.Rule, f.Message)
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+		}
+
+		if n := lintErrors(findings); n > 0 {
+			return fmt.Errorf("the Dockerfile of %s has %d error(s)", project.Name, n)
+		}
+		return nil
+	},
+}
+
+// lint checks the Dockerfile before the build. Errors stop the build,
+// other findings are logged.
+func (r *buildRun) lint() error {
+	findings, err := lintDockerfile(r.project, r.entries, r.ignored)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range findings {
+		msg := fmt.Sprintf("Dockerfile:%d: %s (%s)", f.Line, f.Message, f.Rule)
+		switch f.Severity {
+		case dockerfile.SeverityError:
+			r.log.Error(msg)
+		case dockerfile.SeverityWarning:
+			r.log.Warn(msg)
+		default:
+			r.log.Debug(msg)
+		}
+	}
+	if n := lintErrors(findings); n > 0 {
+		return fmt.Errorf("the Dockerfile has %d error(s), see devx lint %s", n, r.project.Name)
+	}
+	return nil
+}
+
+// lintDockerfile checks the Dockerfile of an assembled build context of project.
+func lintDockerfile(project projects.Project, entries []filesystem.Entry, ignored []filesystem.Ignored) ([]dockerfile.Finding, error) {
+	entry, ok := filesystem.FindEntry(entries, "Dockerfile")
+	if !ok || entry.Source == "" {
+		return nil, fmt.Errorf("no Dockerfile found in the build context of %s", project.Name)
+	}
+	f, err := os.Open(entry.Source)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	d, err := dockerfile.Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", entry.Source, err)
+	}
+
+	// BUILD_DATE is passed to every build
+	args := map[string]string{"BUILD_DATE": currentTimeRFC3339()}
+	maps.Copy(args, project.Build.Args)
+
+	return dockerfile.Lint(d, dockerfile.Options{
+		Entries: withGeneratedFiles(entries, project.Generate),
+		Ignored: ignored,
+		Args:    args,
+		Target:  project.Build.Target,
+		Disable: project.Lint.Disable,
+	})
+}
+
+func lintErrors(findings []dockerfile.Finding) int {
+	n := 0
+	for _, f := range findings {
+		if f.Severity == dockerfile.SeverityError {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/zenginechris/devx/config"
+	"github.com/zenginechris/devx/internal/clients"
+)
+
+// platformsCacheTTL is how long the node platforms of a cluster are cached.
+const platformsCacheTTL = 24 * time.Hour
+
+// defaultPlatform is used if the platforms of the cluster cannot be determined.
+const defaultPlatform = "linux/amd64"
+
+// cachedPlatforms are the node platforms of a cluster at a point in time.
+type cachedPlatforms struct {
+	Platforms []string  `json:"platforms"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+func platformsCacheFile() string {
+	return filepath.Join(config.CacheDir(), "platforms.json")
+}
+
+// clusterPlatforms returns the platforms of the nodes of the current cluster.
+// Results are cached per kube context, refresh forces a new lookup.
+// If the platforms cannot be determined, the default platform is returned.
+func clusterPlatforms(log *logrus.Entry, refresh bool) []string {
+	kubeContext, err := clients.CurrentContext()
+	if err == nil && kubeContext == "" {
+		err = fmt.Errorf("no current context")
+	}
+	if err != nil {
+		log.Warnf("Could not determine the kube context, building for %s: %v", defaultPlatform, err)
+		return []string{defaultPlatform}
+	}
+
+	cache := map[string]cachedPlatforms{}
+	if b, err := os.ReadFile(platformsCacheFile()); err == nil {
+		if err := json.Unmarshal(b, &cache); err != nil {
+			log.Debugf("Ignoring broken platforms cache: %v", err)
+		}
+	}
+
+	if c, ok := cache[kubeContext]; ok && !refresh && time.Since(c.CheckedAt) < platformsCacheTTL && len(c.Platforms) > 0 {
+		return c.Platforms
+	}
+
+	platforms, err := clients.NodePlatforms()
+	if err != nil || len(platforms) == 0 {
+		if err == nil {
+			err = fmt.Errorf("no node reports its architecture")
+		}
+		log.Warnf("Could not determine the node platforms of %s, building for %s: %v", kubeContext, defaultPlatform, err)
+		return []string{defaultPlatform}
+	}
+	log.Debugf("Nodes of %s run on %v", kubeContext, platforms)
+
+	cache[kubeContext] = cachedPlatforms{Platforms: platforms, CheckedAt: time.Now()}
+	if b, err := json.MarshalIndent(cache, "", "  "); err == nil {
+		if err := os.WriteFile(platformsCacheFile(), b, 0644); err != nil {
+			log.Debugf("Could not write platforms cache: %v", err)
+		}
+	}
+
+	return platforms
+}
+
+// preferredPlatform returns the native platform of builder if it is one of
+// platforms, and the first of platforms otherwise. Builders that report no
+// platforms are assumed to build for the architecture of the host.
+func preferredPlatform(builder clients.Builder, platforms []string) string {
+	native := builder.NativePlatform()
+	if native == "" {
+		native = "linux/" + runtime.GOARCH
+	}
+	for _, p := range platforms {
+		// linux/arm64 is native for linux/arm64/v8
+		if p == native || strings.HasPrefix(native, p+"/") {
+			return p
+		}
+	}
+	return platforms[0]
+}
@@ -10,16 +10,11 @@ import (
 	"strings"
 	"text/tabwriter"
 	"text/template"
-	"time"
-
-	"slices"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/zenginechris/devx/cmd/root"
 	"github.com/zenginechris/devx/config"
-	"github.com/zenginechris/devx/internal/clients"
-	"github.com/zenginechris/devx/internal/filesystem"
 	"github.com/zenginechris/devx/internal/projects"
 )
 
@@ -27,7 +22,6 @@ func init() {
 	listProjectsCmd.Flags().BoolVarP(&listProjectsCmdArgs.json, "json", "j", false, "print json output")
 	root.Cmd().AddCommand(listProjectsCmd)
 	root.Cmd().AddCommand(setProjectContextCmd)
-	root.Cmd().AddCommand(buildProjectCmd)
 	root.Cmd().AddCommand(editProjectCmd)
     root.Cmd().AddCommand(createProjectCmd)
 }
@@ -70,6 +64,9 @@ var createProjectCmd = &cobra.Command{
 	Args:    cobra.MinimumNArgs(1),
 	Short:   "Create new project",
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if contextSubcommand(args[0]) {
+			return fmt.Errorf("'%s' is a subcommand of devx context and cannot be used as a project name", args[0])
+		}
 
 		cfg, err := config.Load()
 		if err != nil {
@@ -145,7 +142,7 @@ var editProjectCmd = &cobra.Command{
 
 		switch args[1] {
 		case "docker":
-			c := exec.Command(editor, path.Join(project.ConfigPath, "Dockerfile"))
+			c := exec.Command(editor, project.DockerfilePath())
 			c.Stdin = os.Stdin
 			c.Stdout = os.Stdout
 			c.Stderr = os.Stderr
@@ -156,119 +153,6 @@ var editProjectCmd = &cobra.Command{
 	},
 }
 
-var buildProjectCmd = &cobra.Command{
-	Use:     "build",
-	Aliases: []string{"b"},
-	Args:    cobra.MinimumNArgs(1),
-	Short:   "Build and update",
-	Long:    "Build a new image from the current context and updates the image in the current k8s cluster",
-	RunE: func(cmd *cobra.Command, args []string) error {
-		cfg, err := config.Load()
-		if err != nil {
-			logrus.Error(err)
-		}
-		project := cfg.FindProject(args[0])
-
-		tempDir, err := os.MkdirTemp("", "docker-build-*")
-		if err != nil {
-			logrus.Error(fmt.Sprintf("Failed to create temp directory: %v\n", err))
-			return err
-		}
-		logrus.Info(fmt.Sprintf("Created temporary directory: %s\n", tempDir))
-
-		defer func() {
-			logrus.Info(fmt.Sprintf("Cleaning up temporary directory: %s\n", tempDir))
-			os.RemoveAll(tempDir)
-		}()
-
-		sourcePaths := []string{
-			project.Context,
-			fmt.Sprintf("%s/%s", project.ConfigPath, "Dockerfile"),
-		}
-
-		sourcePaths = append(sourcePaths, project.Contexts...)
-
-		for _, sourcePath := range sourcePaths {
-			err := filesystem.CopyToTemp(sourcePath, tempDir)
-			if err != nil {
-				logrus.Error(fmt.Sprintf("Failed to copy %s: %v\n", sourcePath, err))
-				return err
-			}
-		}
-
-		dockerfilePath := filepath.Join(tempDir, "Dockerfile")
-		if _, err := os.Stat(dockerfilePath); os.IsNotExist(err) {
-			logrus.Error(fmt.Sprintln("Error: No Dockerfile found in the temporary directory."))
-			logrus.Error(fmt.Sprintln("Make sure one of your source paths contains a Dockerfile."))
-			return err
-		}
-
-		checkDriverCmd := exec.Command("docker", "buildx", "inspect")
-		driverOutput, err := checkDriverCmd.CombinedOutput()
-		usingDockerDriver := true
-		if err == nil && len(driverOutput) > 0 {
-			usingDockerDriver = len(driverOutput) > 0 && string(driverOutput) != "" && string(driverOutput) != "null" &&
-				(string(driverOutput) != "Driver: docker" || string(driverOutput) != "docker-container")
-		}
-
-		newImage := fmt.Sprintf("devx_%s:%v", slugify(project.Name), time.Now().UnixMilli())
-
-		buildOpts := []string{
-			"buildx",
-			"build",
-			"--platform=linux/amd64",
-			"--tag", newImage,
-			"--build-arg", "BUILD_DATE=" + currentTimeRFC3339(),
-			"--label", "org.opencontainers.image.created=" + currentTimeRFC3339(),
-			"--progress=plain",
-			"--load",
-			".",
-		}
-
-		if !usingDockerDriver {
-			buildOpts[2] = "--platform=linux/amd64,linux/arm64"
-			buildOpts = append(buildOpts[:4], append([]string{
-				"--cache-from=type=local,src=/tmp/buildcache",
-				"--cache-to=type=local,dest=/tmp/buildcache,mode=max",
-			}, buildOpts[4:]...)...)
-
-			buildOpts = removeOption(buildOpts, "--load")
-		}
-
-		dockerCmd := exec.Command("docker", buildOpts...)
-		dockerCmd.Dir = tempDir
-		dockerCmd.Stdout = os.Stdout
-		dockerCmd.Stderr = os.Stderr
-
-		logrus.Info(fmt.Sprintln("Running docker build command..."))
-		err = dockerCmd.Run()
-		if err != nil {
-			logrus.Error(fmt.Sprintf("Docker build failed: %v\n", err))
-			return err
-		}
-
-		fmt.Println("Docker build completed successfully")
-
-		clients.UpdateDeployment(project, newImage)
-
-		return nil
-
-	},
-}
-
-func currentTimeRFC3339() string {
-	return time.Now().Format(time.RFC3339)
-}
-
-func removeOption(slice []string, option string) []string {
-	for i, item := range slice {
-		if item == option {
-			return slices.Delete(slice, i, i+1)
-		}
-	}
-	return slice
-}
-
 func getEditor() string {
 
 	editor := os.Getenv("EDITOR")
@@ -289,6 +173,20 @@ func getEditor() string {
 	return editor
 }
 
+// loadProject loads the configuration and returns the project with the given name.
+func loadProject(name string) (projects.Project, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return projects.Project{}, err
+	}
+
+	project := cfg.FindProject(name)
+	if project.Name == "" {
+		return project, fmt.Errorf("project '%s' not found", name)
+	}
+	return project, nil
+}
+
 func slugify(input string) string {
 	lowercase := strings.ToLower(input)
 	slugified := strings.ReplaceAll(lowercase, " ", "-")
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/zenginechris/devx/cmd/root"
+	"github.com/zenginechris/devx/internal/clients"
+)
+
+func init() {
+	whoCmd.Flags().BoolVarP(&whoCmdArgs.json, "json", "j", false, "print json output")
+	root.Cmd().AddCommand(whoCmd)
+}
+
+var whoCmdArgs struct {
+	json bool
+}
+
+var whoCmd = &cobra.Command{
+	Use:   "who <namespace>",
+	Args:  cobra.ExactArgs(1),
+	Short: "Show who deployed the devx builds of a namespace",
+	Long:  "List the workloads of a namespace that run images built by devx, with the user, time, revision and context of the build",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		workloads, err := clients.DevxWorkloads(args[0])
+		if err != nil {
+			return err
+		}
+
+		if whoCmdArgs.json {
+			if workloads == nil {
+				workloads = []clients.Workload{}
+			}
+			b, err := json.MarshalIndent(workloads, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(b))
+			return nil
+		}
+
+		if len(workloads) == 0 {
+			fmt.Printf("No workloads in %s run images built by devx\n", args[0])
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "WORKLOAD\tIMAGE\tUSER\tDEPLOYED\tREVISION\tCONTEXT")
+		for _, wl := range workloads {
+			a := func(key string) string {
+				if v := wl.Annotations[key]; v != "" {
+					return v
+				}
+				return "-"
+			}
+			revision, dirty := strings.CutSuffix(a(clients.AnnotationRevision), "-dirty")
+			if len(revision) > 12 {
+				revision = revision[:12]
+			}
+			if dirty {
+				revision += "-dirty"
+			}
+			fmt.Fprintf(w, "%s/%s\t%s\t%s\t%s\t%s\t%s\n", wl.Kind, wl.Name, strings.Join(wl.Images, ","),
+				a(clients.AnnotationUser), a(clients.AnnotationBuiltAt), revision, a(clients.AnnotationContext))
+		}
+		return w.Flush()
+	},
+}
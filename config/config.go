@@ -1,6 +1,7 @@
 package config
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 
@@ -30,7 +31,20 @@ func AppVersion() VersionInfo {
 }
 
 type Config struct {
-	Projects []projects.Project `toml:"projects"`
+	// KeepImages is the number of images per project kept after each deploy,
+	// 0 disables the automatic removal of old images.
+	KeepImages int `toml:"keep_images,omitempty"`
+	// KeepBuildLogs is the number of build logs kept per project, default 20.
+	KeepBuildLogs int                `toml:"keep_build_logs,omitempty"`
+	Projects      []projects.Project `toml:"projects"`
+}
+
+// KeepImagesFor returns the number of images kept for project.
+func (c *Config) KeepImagesFor(project projects.Project) int {
+	if project.KeepImages > 0 {
+		return project.KeepImages
+	}
+	return c.KeepImages
 }
 
 func (c *Config) AddProject(p projects.Project) {
@@ -74,7 +88,8 @@ func LoadFrom(file string) (Config, error) {
 		return c, fmt.Errorf("could not load config from file: %w", err)
 	}
 
-	err = toml.Unmarshal(b, &c)
+	// context entries can be plain strings or tables
+	err = toml.NewDecoder(bytes.NewReader(b)).EnableUnmarshalerInterface().Decode(&c)
 	if err != nil {
 		return c, fmt.Errorf("could not load config from file: %w", err)
 	}
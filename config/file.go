@@ -109,6 +109,46 @@ var (
 		},
 	}
 
+	stagingDir = requiredDir{
+		dir: func() (string, error) {
+			dir, err := cacheDir.dir()
+			if err != nil {
+				return "", err
+			}
+			return filepath.Join(dir, "staging"), nil
+		},
+	}
+
+	manifestsDir = requiredDir{
+		dir: func() (string, error) {
+			dir, err := cacheDir.dir()
+			if err != nil {
+				return "", err
+			}
+			return filepath.Join(dir, "manifests"), nil
+		},
+	}
+
+	buildCacheDir = requiredDir{
+		dir: func() (string, error) {
+			dir, err := cacheDir.dir()
+			if err != nil {
+				return "", err
+			}
+			return filepath.Join(dir, "buildcache"), nil
+		},
+	}
+
+	logsDir = requiredDir{
+		dir: func() (string, error) {
+			dir, err := cacheDir.dir()
+			if err != nil {
+				return "", err
+			}
+			return filepath.Join(dir, "logs"), nil
+		},
+	}
+
 	templatesDir = requiredDir{
 		dir: func() (string, error) {
 			dir, err := configBaseDir.dir()
@@ -134,6 +174,21 @@ var (
 // CacheDir returns the cache directory.
 func CacheDir() string { return cacheDir.Dir() }
 
+// StagingDir returns the directory of the persistent build context staging directories.
+func StagingDir() string { return stagingDir.Dir() }
+
+// ManifestsDir returns the directory of the build context manifests of the last builds.
+func ManifestsDir() string { return manifestsDir.Dir() }
+
+// BuildCacheDir returns the directory of the local build caches of projects.
+func BuildCacheDir() string { return buildCacheDir.Dir() }
+
+// LogsDir returns the directory of the build logs.
+func LogsDir() string { return logsDir.Dir() }
+
+// HistoryFile returns the file of the build history.
+func HistoryFile() string { return filepath.Join(CacheDir(), "history.jsonl") }
+
 // TemplatesDir returns the templates' directory.
 func TemplatesDir() string { return templatesDir.Dir() }
 
@@ -0,0 +1,227 @@
+// Package buildcache exports and imports local buildx caches as archives.
+package buildcache
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// metadataFile is the name of the first entry of a cache archive.
+const metadataFile = "devx-cache.json"
+
+// cachePrefix is the directory of the cache files in a cache archive.
+const cachePrefix = "cache/"
+
+// Metadata describes a cache archive and the builder that produced the cache.
+type Metadata struct {
+	Project         string    `json:"project"`
+	Created         time.Time `json:"created"`
+	Builder         string    `json:"builder,omitempty"`
+	Driver          string    `json:"driver,omitempty"`
+	BuildKitVersion string    `json:"buildkit_version,omitempty"`
+	Platform        string    `json:"platform,omitempty"`
+	Size            int64     `json:"size"`
+	// Files maps the slash separated path of each cache file to its sha256 hash.
+	Files map[string]string `json:"files"`
+}
+
+// Export writes the cache in dir as a tar archive to w. The metadata, including
+// the hashes of all files, is written as the first entry.
+func Export(dir string, meta Metadata, w io.Writer) (Metadata, error) {
+	if _, err := os.Stat(filepath.Join(dir, "index.json")); err != nil {
+		return meta, fmt.Errorf("%s is not a build cache: %w", dir, err)
+	}
+
+	meta.Files = map[string]string{}
+	meta.Size = 0
+	var files []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return fmt.Errorf("unexpected non-regular file %s in build cache", p)
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		hash, size, err := hashFile(p)
+		if err != nil {
+			return err
+		}
+
+		name := filepath.ToSlash(rel)
+		meta.Files[name] = hash
+		meta.Size += size
+		files = append(files, name)
+		return nil
+	})
+	if err != nil {
+		return meta, err
+	}
+
+	tw := tar.NewWriter(w)
+
+	b, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return meta, err
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: metadataFile, Mode: 0644, Size: int64(len(b)), ModTime: meta.Created}); err != nil {
+		return meta, err
+	}
+	if _, err := tw.Write(b); err != nil {
+		return meta, err
+	}
+
+	for _, name := range files {
+		if err := writeFile(tw, filepath.Join(dir, filepath.FromSlash(name)), cachePrefix+name); err != nil {
+			return meta, err
+		}
+	}
+
+	return meta, tw.Close()
+}
+
+func writeFile(tw *tar.Writer, file, name string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: info.Size(), ModTime: info.ModTime()}); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// ReadMetadata reads the metadata of a cache archive.
+func ReadMetadata(r io.Reader) (Metadata, error) {
+	return readMetadata(tar.NewReader(r))
+}
+
+func readMetadata(tr *tar.Reader) (Metadata, error) {
+	var meta Metadata
+
+	hdr, err := tr.Next()
+	if err != nil {
+		return meta, fmt.Errorf("error reading cache archive: %w", err)
+	}
+	if hdr.Name != metadataFile {
+		return meta, fmt.Errorf("not a devx cache archive, missing %s", metadataFile)
+	}
+	if err := json.NewDecoder(tr).Decode(&meta); err != nil {
+		return meta, fmt.Errorf("error reading cache metadata: %w", err)
+	}
+	return meta, nil
+}
+
+// Import extracts the cache archive read from r into dir, which must be empty
+// and is created if it does not exist. Every file is checked against the hash of the metadata and blobs
+// against their digest. On errors dir is removed.
+func Import(r io.Reader, dir string) (meta Metadata, err error) {
+	tr := tar.NewReader(r)
+	meta, err = readMetadata(tr)
+	if err != nil {
+		return meta, err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return meta, err
+	}
+	defer func() {
+		if err != nil {
+			os.RemoveAll(dir)
+		}
+	}()
+
+	seen := map[string]bool{}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return meta, fmt.Errorf("error reading cache archive: %w", err)
+		}
+
+		name, ok := strings.CutPrefix(hdr.Name, cachePrefix)
+		if !ok || hdr.Typeflag != tar.TypeReg || !filepath.IsLocal(name) {
+			return meta, fmt.Errorf("unexpected entry %s in cache archive", hdr.Name)
+		}
+		want, ok := meta.Files[name]
+		if !ok {
+			return meta, fmt.Errorf("%s is not listed in the cache metadata", name)
+		}
+
+		hash, err := extractFile(tr, filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			return meta, err
+		}
+		if hash != want {
+			return meta, fmt.Errorf("%s is corrupt, expected sha256 %s, got %s", name, want, hash)
+		}
+		// blobs are named after their digest
+		if digest, ok := strings.CutPrefix(name, "blobs/sha256/"); ok && path.Base(name) == digest && hash != digest {
+			return meta, fmt.Errorf("blob %s does not match its digest", name)
+		}
+		seen[name] = true
+	}
+
+	for name := range meta.Files {
+		if !seen[name] {
+			return meta, fmt.Errorf("%s is missing from the cache archive", name)
+		}
+	}
+
+	return meta, nil
+}
+
+func extractFile(r io.Reader, file string) (string, error) {
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return "", err
+	}
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(f, h), r); err != nil {
+		return "", fmt.Errorf("error extracting %s: %w", file, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), f.Close()
+}
+
+func hashFile(file string) (string, int64, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
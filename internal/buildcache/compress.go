@@ -0,0 +1,158 @@
+package buildcache
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Create creates the archive file. Files ending in .zst or .zstd are compressed
+// with the zstd command, .gz and .tgz with gzip, others are plain tar archives.
+func Create(file string) (io.WriteCloser, error) {
+	f, err := os.Create(file)
+	if err != nil {
+		return nil, err
+	}
+
+	switch compression(file) {
+	case "zstd":
+		c := exec.Command("zstd", "-q", "-T0", "-c")
+		c.Stdout = f
+		c.Stderr = os.Stderr
+		stdin, err := c.StdinPipe()
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		if err := c.Start(); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("error running zstd, is it installed? %w", err)
+		}
+		return &cmdWriter{WriteCloser: stdin, cmd: c, file: f}, nil
+	case "gzip":
+		return &gzipWriter{Writer: gzip.NewWriter(f), file: f}, nil
+	default:
+		return f, nil
+	}
+}
+
+// Open opens the archive file, decompressing it according to its extension.
+func Open(file string) (io.ReadCloser, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+
+	switch compression(file) {
+	case "zstd":
+		c := exec.Command("zstd", "-q", "-d", "-c")
+		c.Stdin = f
+		c.Stderr = os.Stderr
+		stdout, err := c.StdoutPipe()
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		if err := c.Start(); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("error running zstd, is it installed? %w", err)
+		}
+		return &cmdReader{ReadCloser: stdout, cmd: c, file: f}, nil
+	case "gzip":
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return &gzipReader{Reader: zr, file: f}, nil
+	default:
+		return f, nil
+	}
+}
+
+func compression(file string) string {
+	switch {
+	case strings.HasSuffix(file, ".zst"), strings.HasSuffix(file, ".zstd"):
+		return "zstd"
+	case strings.HasSuffix(file, ".gz"), strings.HasSuffix(file, ".tgz"):
+		return "gzip"
+	default:
+		return ""
+	}
+}
+
+// cmdWriter writes to the stdin of a compression command.
+type cmdWriter struct {
+	io.WriteCloser
+	cmd  *exec.Cmd
+	file *os.File
+}
+
+func (w *cmdWriter) Close() error {
+	err := w.WriteCloser.Close()
+	if waitErr := w.cmd.Wait(); err == nil && waitErr != nil {
+		err = fmt.Errorf("zstd failed: %w", waitErr)
+	}
+	if closeErr := w.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// cmdReader reads from the stdout of a decompression command.
+type cmdReader struct {
+	io.ReadCloser
+	cmd  *exec.Cmd
+	file *os.File
+	eof  bool
+}
+
+func (r *cmdReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if err == io.EOF {
+		r.eof = true
+	}
+	return n, err
+}
+
+// Close reports errors of the command only if its output was read completely,
+// a reader that stops early kills it.
+func (r *cmdReader) Close() error {
+	defer r.file.Close()
+
+	if !r.eof {
+		_ = r.cmd.Process.Kill()
+		_ = r.cmd.Wait()
+		return nil
+	}
+	if err := r.cmd.Wait(); err != nil {
+		return fmt.Errorf("zstd failed: %w", err)
+	}
+	return nil
+}
+
+type gzipWriter struct {
+	*gzip.Writer
+	file *os.File
+}
+
+func (w *gzipWriter) Close() error {
+	err := w.Writer.Close()
+	if closeErr := w.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+type gzipReader struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (r *gzipReader) Close() error {
+	r.Reader.Close()
+	return r.file.Close()
+}
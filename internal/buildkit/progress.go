@@ -0,0 +1,147 @@
+// Package buildkit parses the plain progress output of BuildKit builds.
+package buildkit
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxOutputLines is the number of output lines kept per step.
+const maxOutputLines = 20
+
+var (
+	stepLine   = regexp.MustCompile(`^#(\d+) (.*)$`)
+	outputLine = regexp.MustCompile(`^\d+\.\d+ `)
+)
+
+// Step is a step (vertex) of a build.
+type Step struct {
+	ID   string
+	Name string
+	// Output are the last lines of the output of the step.
+	Output []string
+	// Error is the error of a failed step.
+	Error string
+	// Cached reports a step whose result was taken from the build cache.
+	Cached bool
+	// Duration is the duration reported when the step finished.
+	Duration time.Duration
+}
+
+// Instruction reports whether the step runs a Dockerfile instruction,
+// e.g. [2/3] RUN make or [builder 1/4] FROM golang.
+func (s Step) Instruction() bool {
+	return strings.HasPrefix(s.Name, "[") && !strings.HasPrefix(s.Name, "[internal]")
+}
+
+// Progress is an io.Writer that parses the output of
+// docker buildx build --progress=plain.
+type Progress struct {
+	mu      sync.Mutex
+	partial []byte
+	steps   map[string]*Step
+	order   []string
+	errors  []string
+}
+
+// NewProgress returns an empty progress.
+func NewProgress() *Progress {
+	return &Progress{steps: map[string]*Step{}}
+}
+
+// Write implements io.Writer.
+func (p *Progress) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.partial = append(p.partial, b...)
+	for {
+		i := bytes.IndexByte(p.partial, '\n')
+		if i < 0 {
+			break
+		}
+		p.parseLine(strings.TrimRight(string(p.partial[:i]), "\r"))
+		p.partial = p.partial[i+1:]
+	}
+	return len(b), nil
+}
+
+func (p *Progress) parseLine(line string) {
+	m := stepLine.FindStringSubmatch(line)
+	if m == nil {
+		if strings.HasPrefix(line, "ERROR: ") {
+			p.errors = append(p.errors, strings.TrimPrefix(line, "ERROR: "))
+		}
+		return
+	}
+
+	id, text := m[1], m[2]
+	s, ok := p.steps[id]
+	if !ok {
+		// the first line of a step is its name
+		s = &Step{ID: id, Name: text}
+		p.steps[id] = s
+		p.order = append(p.order, id)
+		return
+	}
+
+	switch {
+	case strings.HasPrefix(text, "ERROR: "):
+		s.Error = strings.TrimPrefix(text, "ERROR: ")
+	case text == "CACHED":
+		s.Cached = true
+	case strings.HasPrefix(text, "DONE "):
+		if d, err := time.ParseDuration(strings.TrimPrefix(text, "DONE ")); err == nil {
+			s.Duration = d
+		}
+	case outputLine.MatchString(text):
+		s.Output = append(s.Output, text)
+		if len(s.Output) > maxOutputLines {
+			s.Output = s.Output[len(s.Output)-maxOutputLines:]
+		}
+	}
+}
+
+// Steps returns the steps in the order they started.
+func (p *Progress) Steps() []Step {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	steps := make([]Step, 0, len(p.order))
+	for _, id := range p.order {
+		steps = append(steps, *p.steps[id])
+	}
+	return steps
+}
+
+// FailedStep returns the first step that failed.
+func (p *Progress) FailedStep() (Step, bool) {
+	for _, s := range p.Steps() {
+		if s.Error != "" {
+			return s, true
+		}
+	}
+	return Step{}, false
+}
+
+// Errors returns the errors reported by the build outside of steps,
+// e.g. ERROR: failed to solve: ...
+func (p *Progress) Errors() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.errors...)
+}
+
+// Timings returns the timings of the Dockerfile instructions of the build.
+func (p *Progress) Timings() []StepTiming {
+	var timings []StepTiming
+	for _, s := range p.Steps() {
+		if s.Instruction() {
+			timings = append(timings, StepTiming{Name: s.Name, Cached: s.Cached, Duration: s.Duration})
+		}
+	}
+	return timings
+}
@@ -0,0 +1,70 @@
+package buildkit
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+// StepTiming is the timing of a Dockerfile instruction of a build.
+type StepTiming struct {
+	Name     string        `json:"name"`
+	Cached   bool          `json:"cached,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// CacheHits returns the number of cached steps.
+func CacheHits(steps []StepTiming) int {
+	var hits int
+	for _, s := range steps {
+		if s.Cached {
+			hits++
+		}
+	}
+	return hits
+}
+
+// Slowest returns the n slowest steps, slowest first.
+func Slowest(steps []StepTiming, n int) []StepTiming {
+	sorted := slices.Clone(steps)
+	slices.SortStableFunc(sorted, func(a, b StepTiming) int { return cmp.Compare(b.Duration, a.Duration) })
+	if len(sorted) > n {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
+
+// StepChange compares the timing of a step with the previous build.
+type StepChange struct {
+	StepTiming
+	// Previous is the timing of the step in the previous build, nil for new steps.
+	Previous *StepTiming
+}
+
+// Delta returns how much slower the step got, 0 for new steps.
+func (c StepChange) Delta() time.Duration {
+	if c.Previous == nil {
+		return 0
+	}
+	return c.Duration - c.Previous.Duration
+}
+
+// Regression reports a step that took at least 50% and one second longer
+// than in the previous build.
+func (c StepChange) Regression() bool {
+	return c.Previous != nil && c.Delta() >= time.Second && c.Duration >= c.Previous.Duration*3/2
+}
+
+// Compare matches the steps of a build with the steps of the same name of
+// the previous build.
+func Compare(steps, previous []StepTiming) []StepChange {
+	changes := make([]StepChange, 0, len(steps))
+	for _, s := range steps {
+		c := StepChange{StepTiming: s}
+		if i := slices.IndexFunc(previous, func(p StepTiming) bool { return p.Name == s.Name }); i >= 0 {
+			c.Previous = &previous[i]
+		}
+		changes = append(changes, c)
+	}
+	return changes
+}
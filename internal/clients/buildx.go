@@ -0,0 +1,127 @@
+package clients
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+// DriverDocker is the buildx driver that builds with the docker daemon.
+const DriverDocker = "docker"
+
+// DriverDockerContainer is the buildx driver that runs BuildKit in a container.
+const DriverDockerContainer = "docker-container"
+
+// Builder is a buildx builder as reported by docker buildx inspect.
+type Builder struct {
+	Name      string
+	Driver    string
+	Status    string
+	Platforms []string
+	// BuildKitVersion is the BuildKit version of the first node.
+	BuildKitVersion string
+}
+
+// NativePlatform returns the preferred platform of the builder, if known.
+func (b Builder) NativePlatform() string {
+	if len(b.Platforms) == 0 {
+		return ""
+	}
+	return b.Platforms[0]
+}
+
+// Supports reports whether the builder can build platform. Builders that
+// report no platforms, e.g. because they are not bootstrapped, support any.
+func (b Builder) Supports(platform string) bool {
+	return len(b.Platforms) == 0 || slices.Contains(b.Platforms, platform)
+}
+
+// InspectBuilder returns the buildx builder with name, or the current builder
+// if name is empty.
+func InspectBuilder(name string) (Builder, error) {
+	args := []string{"buildx", "inspect"}
+	if name != "" {
+		args = append(args, name)
+	}
+
+	var stderr bytes.Buffer
+	c := exec.Command("docker", args...)
+	c.Stderr = &stderr
+	out, err := c.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return Builder{}, fmt.Errorf("error inspecting buildx builder: %s", msg)
+		}
+		return Builder{}, fmt.Errorf("error inspecting buildx builder: %w", err)
+	}
+
+	b := parseBuilder(out)
+	if b.Name == "" || b.Driver == "" {
+		return Builder{}, fmt.Errorf("unexpected output of docker buildx inspect")
+	}
+	return b, nil
+}
+
+// parseBuilder parses the output of docker buildx inspect. The builder fields
+// come first, followed by a section per node after the Nodes: line.
+func parseBuilder(out []byte) Builder {
+	var b Builder
+	inNodes := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "Nodes:" {
+			inNodes = true
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+
+		switch {
+		case !inNodes && key == "Name":
+			b.Name = value
+		case !inNodes && key == "Driver":
+			b.Driver = value
+		case inNodes && key == "Status" && b.Status == "":
+			b.Status = value
+		case inNodes && (key == "BuildKit version" || key == "Buildkit") && b.BuildKitVersion == "":
+			b.BuildKitVersion = value
+		case inNodes && key == "Platforms":
+			for _, p := range strings.Split(value, ",") {
+				// the preferred platforms are marked with a trailing *
+				p = strings.TrimSuffix(strings.TrimSpace(p), "*")
+				if p != "" && !slices.Contains(b.Platforms, p) {
+					b.Platforms = append(b.Platforms, p)
+				}
+			}
+		}
+	}
+
+	return b
+}
+
+// CreateBuilder creates and starts a buildx builder with the docker-container
+// driver. buildkitdConfig is an optional buildkitd.toml.
+func CreateBuilder(name, buildkitdConfig string) error {
+	args := []string{"buildx", "create", "--name", name, "--driver", DriverDockerContainer, "--bootstrap"}
+	if buildkitdConfig != "" {
+		args = append(args, "--buildkitd-config", buildkitdConfig)
+	}
+
+	c := exec.Command("docker", args...)
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	if err := c.Run(); err != nil {
+		return fmt.Errorf("error creating buildx builder %s: %w", name, err)
+	}
+	return nil
+}
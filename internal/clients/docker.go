@@ -0,0 +1,126 @@
+package clients
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Image is an image of the local docker image store.
+type Image struct {
+	Repository string    `json:"repository"`
+	Tag        string    `json:"tag"`
+	ID         string    `json:"id"`
+	Created    time.Time `json:"created"`
+	Size       string    `json:"size"`
+}
+
+// Ref returns the repository and tag of the image.
+func (i Image) Ref() string {
+	return i.Repository + ":" + i.Tag
+}
+
+// ListImages returns the local images whose repository matches pattern,
+// e.g. devx_*, newest first.
+func ListImages(pattern string) ([]Image, error) {
+	var stderr bytes.Buffer
+	c := exec.Command("docker", "image", "ls", "--no-trunc", "--filter", "reference="+pattern, "--format", "{{json .}}")
+	c.Stderr = &stderr
+	out, err := c.Output()
+	if err != nil {
+		return nil, fmt.Errorf("error listing images: %s", strings.TrimSpace(stderr.String()+" "+err.Error()))
+	}
+
+	var images []Image
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		var raw struct {
+			Repository, Tag, ID, CreatedAt, Size string
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &raw); err != nil {
+			return nil, fmt.Errorf("unexpected output of docker image ls: %w", err)
+		}
+		if raw.Tag == "<none>" {
+			continue
+		}
+
+		created, err := time.Parse("2006-01-02 15:04:05 -0700 MST", raw.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected creation time of %s:%s: %w", raw.Repository, raw.Tag, err)
+		}
+		images = append(images, Image{
+			Repository: raw.Repository,
+			Tag:        raw.Tag,
+			ID:         raw.ID,
+			Created:    created,
+			Size:       raw.Size,
+		})
+	}
+
+	slices.SortStableFunc(images, func(a, b Image) int { return b.Created.Compare(a.Created) })
+	return images, nil
+}
+
+// RemoveImage removes the image tag ref. The image itself is only deleted
+// once no tag references it.
+func RemoveImage(ref string) error {
+	out, err := exec.Command("docker", "image", "rm", ref).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("error removing image %s: %s", ref, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// NormalizeImageRef strips the default registry and library prefixes the
+// container runtime may add to references of local images.
+func NormalizeImageRef(ref string) string {
+	ref = strings.TrimPrefix(ref, "docker.io/")
+	return strings.TrimPrefix(ref, "library/")
+}
+
+// ImageLayer is a layer of an image with the instruction that created it.
+type ImageLayer struct {
+	CreatedBy string `json:"created_by"`
+	Size      int64  `json:"size"`
+}
+
+// ImageLayers returns the layers of the local image ref, oldest first.
+func ImageLayers(ref string) ([]ImageLayer, error) {
+	var stderr bytes.Buffer
+	c := exec.Command("docker", "image", "history", "--human=false", "--no-trunc", "--format", "{{json .}}", ref)
+	c.Stderr = &stderr
+	out, err := c.Output()
+	if err != nil {
+		return nil, fmt.Errorf("error reading the history of %s: %s", ref, strings.TrimSpace(stderr.String()+" "+err.Error()))
+	}
+
+	var layers []ImageLayer
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var raw struct {
+			CreatedBy, Size string
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &raw); err != nil {
+			return nil, fmt.Errorf("unexpected output of docker image history: %w", err)
+		}
+		size, err := strconv.ParseInt(raw.Size, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected layer size '%s' of %s", raw.Size, ref)
+		}
+		layers = append(layers, ImageLayer{CreatedBy: raw.CreatedBy, Size: size})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// docker lists the newest layer first
+	slices.Reverse(layers)
+	return layers, nil
+}
@@ -3,16 +3,20 @@ package clients
 import (
 	"context"
 	"fmt"
-	"os"
+	"maps"
+	"slices"
+	"strings"
 
 	"github.com/zenginechris/devx/internal/projects"
-    corev1 "k8s.io/api/core/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
-func UpdateDeployment(project projects.Project, imageTag string) {
+// newClientset creates a kubernetes clientset for the current kube context.
+// It returns the clientset together with the name of the context in use.
+func newClientset() (*kubernetes.Clientset, string, error) {
 	var useContext string
 	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
 	configOverrides := &clientcmd.ConfigOverrides{}
@@ -22,40 +26,252 @@ func UpdateDeployment(project projects.Project, imageTag string) {
 		configOverrides.CurrentContext = useContext
 	}
 
-	// Set context if specified
-	if useContext != "" {
-		configOverrides.CurrentContext = useContext
-	}
-
 	// Get the clientConfig respecting the current context
 	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
 
 	config, err := clientConfig.ClientConfig()
 	if err != nil {
-		fmt.Printf("Error building kubeconfig: %s\n", err.Error())
-		os.Exit(1)
+		return nil, "", fmt.Errorf("error building kubeconfig: %w", err)
 	}
 
 	// Create the clientset
 	clientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
-		fmt.Printf("Error creating kubernetes client: %s\n", err.Error())
-		os.Exit(1)
+		return nil, "", fmt.Errorf("error creating kubernetes client: %w", err)
 	}
 
 	// Get current context name
+	var currentContext string
 	rawConfig, err := clientConfig.RawConfig()
 	if err != nil {
 		fmt.Printf("Warning: couldn't get current context name: %s\n", err.Error())
 	} else {
-		fmt.Printf("Using Kubernetes context: %s\n", rawConfig.CurrentContext)
+		currentContext = rawConfig.CurrentContext
+	}
+
+	return clientset, currentContext, nil
+}
+
+// CurrentContext returns the name of the current kube context without
+// contacting the cluster.
+func CurrentContext() (string, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{})
+
+	rawConfig, err := clientConfig.RawConfig()
+	if err != nil {
+		return "", fmt.Errorf("error loading kubeconfig: %w", err)
+	}
+	return rawConfig.CurrentContext, nil
+}
+
+// NodePlatforms returns the distinct platforms of all nodes of the cluster,
+// e.g. linux/arm64, in lexical order.
+func NodePlatforms() ([]string, error) {
+	clientset, _, err := newClientset()
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := clientset.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing nodes: %w", err)
+	}
+
+	var platforms []string
+	for _, node := range nodes.Items {
+		info := node.Status.NodeInfo
+		if info.Architecture == "" {
+			continue
+		}
+		os := info.OperatingSystem
+		if os == "" {
+			os = "linux"
+		}
+		platform := os + "/" + info.Architecture
+		if !slices.Contains(platforms, platform) {
+			platforms = append(platforms, platform)
+		}
+	}
+	slices.Sort(platforms)
+
+	return platforms, nil
+}
+
+// ClusterImages returns the normalized images referenced by the pods and
+// workloads of all namespaces.
+func ClusterImages() (map[string]bool, error) {
+	clientset, _, err := newClientset()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.TODO()
+	images := map[string]bool{}
+	addSpec := func(spec corev1.PodSpec) {
+		for _, c := range slices.Concat(spec.InitContainers, spec.Containers) {
+			images[NormalizeImageRef(c.Image)] = true
+		}
+	}
+
+	pods, err := clientset.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing pods: %w", err)
+	}
+	for _, p := range pods.Items {
+		addSpec(p.Spec)
+	}
+
+	deployments, err := clientset.AppsV1().Deployments(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing deployments: %w", err)
+	}
+	for _, d := range deployments.Items {
+		addSpec(d.Spec.Template.Spec)
+	}
+
+	statefulSets, err := clientset.AppsV1().StatefulSets(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing statefulsets: %w", err)
+	}
+	for _, s := range statefulSets.Items {
+		addSpec(s.Spec.Template.Spec)
+	}
+
+	daemonSets, err := clientset.AppsV1().DaemonSets(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing daemonsets: %w", err)
+	}
+	for _, d := range daemonSets.Items {
+		addSpec(d.Spec.Template.Spec)
+	}
+
+	cronJobs, err := clientset.BatchV1().CronJobs(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing cronjobs: %w", err)
+	}
+	for _, c := range cronJobs.Items {
+		addSpec(c.Spec.JobTemplate.Spec.Template.Spec)
+	}
+
+	return images, nil
+}
+
+// Annotations set by devx on deployed workloads and their pod templates.
+const (
+	AnnotationPrefix   = "devx.io/"
+	AnnotationProject  = AnnotationPrefix + "project"
+	AnnotationBuiltAt  = AnnotationPrefix + "built-at"
+	AnnotationContext  = AnnotationPrefix + "context"
+	AnnotationDigest   = AnnotationPrefix + "context-digest"
+	AnnotationRevision = AnnotationPrefix + "revision"
+	AnnotationUser     = AnnotationPrefix + "user"
+	AnnotationVersion  = AnnotationPrefix + "version"
+)
+
+// Workload is a workload running devx built images.
+type Workload struct {
+	Kind      string   `json:"kind"`
+	Namespace string   `json:"namespace"`
+	Name      string   `json:"name"`
+	Images    []string `json:"images"`
+	// Annotations are the devx.io annotations of the workload.
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// DevxWorkloads returns the deployments, statefulsets and daemonsets of
+// namespace whose containers run images built by devx.
+func DevxWorkloads(namespace string) ([]Workload, error) {
+	clientset, _, err := newClientset()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.TODO()
+	var workloads []Workload
+	add := func(kind string, meta metav1.ObjectMeta, spec corev1.PodSpec) {
+		w := Workload{Kind: kind, Namespace: meta.Namespace, Name: meta.Name}
+		for _, c := range spec.Containers {
+			if image := NormalizeImageRef(c.Image); strings.HasPrefix(image, "devx_") {
+				w.Images = append(w.Images, image)
+			}
+		}
+		if len(w.Images) == 0 {
+			return
+		}
+		for k, v := range meta.Annotations {
+			if strings.HasPrefix(k, AnnotationPrefix) {
+				if w.Annotations == nil {
+					w.Annotations = map[string]string{}
+				}
+				w.Annotations[k] = v
+			}
+		}
+		workloads = append(workloads, w)
+	}
+
+	deployments, err := clientset.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing deployments: %w", err)
+	}
+	for _, d := range deployments.Items {
+		add("deployment", d.ObjectMeta, d.Spec.Template.Spec)
+	}
+
+	statefulSets, err := clientset.AppsV1().StatefulSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing statefulsets: %w", err)
+	}
+	for _, s := range statefulSets.Items {
+		add("statefulset", s.ObjectMeta, s.Spec.Template.Spec)
+	}
+
+	daemonSets, err := clientset.AppsV1().DaemonSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing daemonsets: %w", err)
+	}
+	for _, d := range daemonSets.Items {
+		add("daemonset", d.ObjectMeta, d.Spec.Template.Spec)
+	}
+
+	return workloads, nil
+}
+
+// DeploymentImages returns the images of all containers of the project
+// deployment and the annotations of its pod template.
+func DeploymentImages(project projects.Project) ([]string, map[string]string, error) {
+	clientset, _, err := newClientset()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	deployment, err := clientset.AppsV1().Deployments(project.Namespace).Get(context.TODO(), project.DeploymentName, metav1.GetOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("error getting deployment: %w", err)
+	}
+
+	var images []string
+	for _, container := range deployment.Spec.Template.Spec.Containers {
+		images = append(images, container.Image)
+	}
+	return images, deployment.Spec.Template.Annotations, nil
+}
+
+// UpdateDeployment sets the image of all containers of the project deployment
+// and adds annotations to the deployment and its pod template.
+func UpdateDeployment(project projects.Project, imageTag string, annotations map[string]string) error {
+	clientset, currentContext, err := newClientset()
+	if err != nil {
+		return err
+	}
+	if currentContext != "" {
+		fmt.Printf("Using Kubernetes context: %s\n", currentContext)
 	}
 
 	// Get the deployment
 	deployment, err := clientset.AppsV1().Deployments(project.Namespace).Get(context.TODO(), project.DeploymentName, metav1.GetOptions{})
 	if err != nil {
-		fmt.Printf("Error getting deployment: %s\n", err.Error())
-		os.Exit(1)
+		return fmt.Errorf("error getting deployment: %w", err)
 	}
 	containerUpdated := false
 	fmt.Printf("Updating deployment %s containers:\n", project.DeploymentName)
@@ -68,8 +284,7 @@ func UpdateDeployment(project projects.Project, imageTag string) {
 
 		container.Image = imageTag
 
-
-        	// Set image pull policy to Never
+		// Set image pull policy to Never
 		pullPolicyBefore := container.ImagePullPolicy
 		container.ImagePullPolicy = corev1.PullNever
 		fmt.Printf("    Pull Policy: %s -> %s\n", pullPolicyBefore, container.ImagePullPolicy)
@@ -78,13 +293,24 @@ func UpdateDeployment(project projects.Project, imageTag string) {
 	}
 
 	if !containerUpdated {
-		fmt.Println("No containers found in the deployment")
-		os.Exit(1)
+		return fmt.Errorf("no containers found in the deployment")
 	}
+
+	if len(annotations) > 0 {
+		if deployment.Annotations == nil {
+			deployment.Annotations = map[string]string{}
+		}
+		if deployment.Spec.Template.Annotations == nil {
+			deployment.Spec.Template.Annotations = map[string]string{}
+		}
+		maps.Copy(deployment.Annotations, annotations)
+		maps.Copy(deployment.Spec.Template.Annotations, annotations)
+	}
+
 	_, err = clientset.AppsV1().Deployments(project.Namespace).Update(context.TODO(), deployment, metav1.UpdateOptions{})
 	if err != nil {
-		fmt.Printf("Error updating deployment: %s\n", err.Error())
-		os.Exit(1)
+		return fmt.Errorf("error updating deployment: %w", err)
 	}
 
+	return nil
 }
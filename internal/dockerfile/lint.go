@@ -0,0 +1,407 @@
+package dockerfile
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zenginechris/devx/internal/filesystem"
+)
+
+// Rule IDs.
+const (
+	// RuleMissingSource is a COPY or ADD source that is not part of the build context.
+	RuleMissingSource = "missing-source"
+	// RuleLatestTag is a base image with the latest tag or without a tag.
+	RuleLatestTag = "latest-tag"
+	// RuleUnpinnedImage is a base image with a tag but without a digest.
+	RuleUnpinnedImage = "unpinned-image"
+	// RuleRootUser is a final stage that runs as root.
+	RuleRootUser = "root-user"
+	// RuleAptCleanup is an apt install that keeps the package lists in the layer.
+	RuleAptCleanup = "apt-cleanup"
+	// RuleUnsetArg is an ARG without a default that is used but not passed to the build.
+	RuleUnsetArg = "unset-arg"
+)
+
+// Rules are the IDs of all rules.
+var Rules = []string{RuleMissingSource, RuleLatestTag, RuleUnpinnedImage, RuleRootUser, RuleAptCleanup, RuleUnsetArg}
+
+// Finding severities.
+const (
+	// SeverityError is a mistake that fails the build.
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// predefinedArgs are the build args set by BuildKit.
+var predefinedArgs = []string{
+	"TARGETPLATFORM", "TARGETOS", "TARGETARCH", "TARGETVARIANT",
+	"BUILDPLATFORM", "BUILDOS", "BUILDARCH", "BUILDVARIANT",
+	"HTTP_PROXY", "HTTPS_PROXY", "FTP_PROXY", "NO_PROXY", "ALL_PROXY",
+	"http_proxy", "https_proxy", "ftp_proxy", "no_proxy", "all_proxy",
+}
+
+var (
+	variable   = regexp.MustCompile(`\$(?:\{([a-zA-Z_][a-zA-Z0-9_]*)((?::?[-+])[^}]*)?\}|([a-zA-Z_][a-zA-Z0-9_]*))`)
+	aptInstall = regexp.MustCompile(`\bapt(-get)?\s+(-\S+\s+)*install\b`)
+)
+
+// Finding is a problem found in a Dockerfile.
+type Finding struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Line     int    `json:"line"`
+	Message  string `json:"message"`
+}
+
+// Options are the build settings a Dockerfile is checked against.
+type Options struct {
+	// Entries and Ignored are the assembled build context.
+	Entries []filesystem.Entry
+	Ignored []filesystem.Ignored
+	// Args are the build args passed to the build.
+	Args map[string]string
+	// Target is the stage that is built, the last stage if empty.
+	Target string
+	// Disable are the IDs of the rules that are not checked.
+	Disable []string
+}
+
+// Lint checks the stages of d that are needed to build the target and
+// returns the findings sorted by line.
+func Lint(d *Dockerfile, opts Options) ([]Finding, error) {
+	for _, id := range opts.Disable {
+		if !slices.Contains(Rules, id) {
+			return nil, fmt.Errorf("unknown lint rule '%s', expected one of %s", id, strings.Join(Rules, ", "))
+		}
+	}
+	if len(d.Stages) == 0 {
+		return nil, fmt.Errorf("the Dockerfile has no FROM instruction")
+	}
+
+	l := &linter{d: d, opts: opts, globals: map[string]string{}}
+	for _, inst := range d.Args {
+		for _, arg := range inst.Args {
+			name, value, _ := strings.Cut(arg, "=")
+			l.globals[name] = value
+		}
+	}
+
+	target := len(d.Stages) - 1
+	if opts.Target != "" {
+		if i := l.stageIndex(opts.Target, len(d.Stages)); i >= 0 {
+			target = i
+		}
+	}
+	stages := l.reachable(target)
+
+	l.checkBaseImages(stages)
+	l.checkSources(stages)
+	l.checkUser(target)
+	l.checkApt(stages)
+	l.checkArgs(stages)
+
+	findings := slices.DeleteFunc(l.findings, func(f Finding) bool { return slices.Contains(opts.Disable, f.Rule) })
+	sort.SliceStable(findings, func(i, j int) bool { return findings[i].Line < findings[j].Line })
+	return findings, nil
+}
+
+type linter struct {
+	d        *Dockerfile
+	opts     Options
+	globals  map[string]string
+	findings []Finding
+}
+
+func (l *linter) add(rule, severity string, line int, format string, a ...any) {
+	l.findings = append(l.findings, Finding{Rule: rule, Severity: severity, Line: line, Message: fmt.Sprintf(format, a...)})
+}
+
+// stageIndex returns the index of the stage named or numbered ref before stage before, or -1.
+func (l *linter) stageIndex(ref string, before int) int {
+	if n, err := strconv.Atoi(ref); err == nil {
+		if n >= 0 && n < before {
+			return n
+		}
+		return -1
+	}
+	for i, s := range l.d.Stages[:before] {
+		if s.Name != "" && s.Name == strings.ToLower(ref) {
+			return i
+		}
+	}
+	return -1
+}
+
+// reachable returns the indexes of target and the stages it depends on.
+func (l *linter) reachable(target int) []int {
+	seen := map[int]bool{}
+	var visit func(i int)
+	visit = func(i int) {
+		if i < 0 || seen[i] {
+			return
+		}
+		seen[i] = true
+		s := l.d.Stages[i]
+		visit(l.stageIndex(s.Base, i))
+		for _, inst := range s.Instructions {
+			if from, ok := inst.Flag("from"); ok {
+				visit(l.stageIndex(from, i))
+			}
+			for _, f := range inst.Flags {
+				if mount, ok := strings.CutPrefix(f, "--mount="); ok {
+					for _, opt := range strings.Split(mount, ",") {
+						if from, ok := strings.CutPrefix(opt, "from="); ok {
+							visit(l.stageIndex(from, i))
+						}
+					}
+				}
+			}
+		}
+	}
+	visit(target)
+
+	stages := make([]int, 0, len(seen))
+	for i := range seen {
+		stages = append(stages, i)
+	}
+	slices.Sort(stages)
+	return stages
+}
+
+// expand replaces the global args in s. It reports false if an arg has no value.
+func (l *linter) expand(s string) (string, bool) {
+	ok := true
+	expanded := variable.ReplaceAllStringFunc(s, func(v string) string {
+		m := variable.FindStringSubmatch(v)
+		name := m[1] + m[3]
+		value, set := l.opts.Args[name]
+		if !set {
+			value = l.globals[name]
+		}
+		if def, isDefault := strings.CutPrefix(strings.TrimPrefix(m[2], ":"), "-"); isDefault && value == "" {
+			value = def
+		}
+		if value == "" {
+			ok = false
+		}
+		return value
+	})
+	return expanded, ok
+}
+
+// checkBaseImages reports base images that are not pinned to a version or digest.
+func (l *linter) checkBaseImages(stages []int) {
+	for _, i := range stages {
+		s := l.d.Stages[i]
+		if l.stageIndex(s.Base, i) >= 0 {
+			continue
+		}
+		image, ok := l.expand(s.Base)
+		if !ok || image == "" || image == "scratch" {
+			continue
+		}
+
+		name, digest, pinned := strings.Cut(image, "@")
+		if pinned && digest != "" {
+			continue
+		}
+		tag := ""
+		if j := strings.LastIndex(name, ":"); j > strings.LastIndex(name, "/") {
+			tag = name[j+1:]
+		}
+		switch tag {
+		case "":
+			l.add(RuleLatestTag, SeverityWarning, s.From.Line, "base image %s has no tag and uses latest, pin a version", image)
+		case "latest":
+			l.add(RuleLatestTag, SeverityWarning, s.From.Line, "base image %s uses the latest tag, pin a version", image)
+		default:
+			l.add(RuleUnpinnedImage, SeverityInfo, s.From.Line, "base image %s is not pinned to a digest", image)
+		}
+	}
+}
+
+// checkSources reports COPY and ADD sources that are missing from the build context.
+func (l *linter) checkSources(stages []int) {
+	for _, i := range stages {
+		for _, inst := range l.d.Stages[i].Instructions {
+			if inst.Cmd != "COPY" && inst.Cmd != "ADD" || len(inst.Args) < 2 {
+				continue
+			}
+			if _, ok := inst.Flag("from"); ok {
+				continue
+			}
+			for _, src := range inst.Args[:len(inst.Args)-1] {
+				if strings.HasPrefix(src, "<<") || strings.Contains(src, "$") ||
+					inst.Cmd == "ADD" && (strings.Contains(src, "://") || strings.HasPrefix(src, "git@")) {
+					continue
+				}
+				name := path.Clean(strings.TrimPrefix(src, "/"))
+				if name == "." {
+					continue
+				}
+				pm, err := sourceMatcher(name)
+				if err != nil || l.inContext(name, pm) {
+					// the builder reports invalid patterns
+					continue
+				}
+				if rule, ok := l.ignoredBy(name, pm); ok {
+					l.add(RuleMissingSource, SeverityError, inst.Line, "%s source %s is excluded from the build context by %s", inst.Cmd, src, rule)
+				} else {
+					l.add(RuleMissingSource, SeverityError, inst.Line, "%s source %s is not part of the build context", inst.Cmd, src)
+				}
+			}
+		}
+	}
+}
+
+// sourceMatcher returns a matcher for a source with wildcards, which are
+// evaluated like .dockerignore patterns. It returns nil for a plain path.
+func sourceMatcher(name string) (*filesystem.PatternMatcher, error) {
+	if !strings.ContainsAny(name, `*?[\`) {
+		return nil, nil
+	}
+	return filesystem.NewPatternMatcher([]string{name})
+}
+
+// inContext reports whether the source name, or the wildcard pattern pm,
+// matches an entry of the context.
+func (l *linter) inContext(name string, pm *filesystem.PatternMatcher) bool {
+	if pm == nil {
+		return filesystem.HasEntry(l.opts.Entries, name)
+	}
+	return slices.ContainsFunc(l.opts.Entries, func(e filesystem.Entry) bool { return pm.Matches(e.Name) })
+}
+
+// ignoredBy returns the ignore rule that excluded the source name, one of its
+// parents or a path matched by the wildcard pattern pm.
+func (l *linter) ignoredBy(name string, pm *filesystem.PatternMatcher) (filesystem.Rule, bool) {
+	for _, ignored := range l.opts.Ignored {
+		if ignored.Name == name || strings.HasPrefix(name, ignored.Name+"/") {
+			return ignored.Rule, true
+		}
+		if pm != nil && pm.Matches(ignored.Name) {
+			return ignored.Rule, true
+		}
+	}
+	return filesystem.Rule{}, false
+}
+
+// checkUser reports a target stage that does not switch to a non-root user.
+func (l *linter) checkUser(target int) {
+	for i := target; i >= 0; {
+		s := l.d.Stages[i]
+		for _, inst := range slices.Backward(s.Instructions) {
+			if inst.Cmd != "USER" || len(inst.Args) == 0 {
+				continue
+			}
+			user, _, _ := strings.Cut(inst.Args[0], ":")
+			if user == "root" || user == "0" {
+				l.add(RuleRootUser, SeverityWarning, inst.Line, "the image runs as root, switch to an unprivileged USER")
+			}
+			return
+		}
+		i = l.stageIndex(s.Base, i)
+	}
+	s := l.d.Stages[target]
+	l.add(RuleRootUser, SeverityWarning, s.From.Line, "the image has no USER instruction and runs as root unless base image %s sets a user", s.Base)
+}
+
+// checkApt reports apt installs that do not remove the package lists.
+func (l *linter) checkApt(stages []int) {
+	for _, i := range stages {
+		for _, inst := range l.d.Stages[i].Instructions {
+			if inst.Cmd != "RUN" {
+				continue
+			}
+			text := inst.Text()
+			if !aptInstall.MatchString(text) || strings.Contains(text, "/var/lib/apt/lists") {
+				continue
+			}
+			// a cache mount keeps the lists out of the layer
+			cached := slices.ContainsFunc(inst.Flags, func(f string) bool {
+				return strings.HasPrefix(f, "--mount=") && strings.Contains(f, "/var/lib/apt")
+			})
+			if !cached {
+				l.add(RuleAptCleanup, SeverityWarning, inst.Line, "apt install keeps the package lists in the layer, add rm -rf /var/lib/apt/lists/*")
+			}
+		}
+	}
+}
+
+// checkArgs reports args without a default that are used but not passed to the build.
+func (l *linter) checkArgs(stages []int) {
+	unset := func(name string) bool {
+		_, passed := l.opts.Args[name]
+		return !passed && !slices.Contains(predefinedArgs, name)
+	}
+	reported := map[string]bool{}
+	report := func(name string, line int) {
+		if !reported[name] {
+			reported[name] = true
+			l.add(RuleUnsetArg, SeverityWarning, line, "ARG %s has no default and is not passed to the build, set it in the build args of the project", name)
+		}
+	}
+
+	for _, i := range stages {
+		s := l.d.Stages[i]
+		for _, name := range variables(s.Base) {
+			if value, ok := l.globals[name]; ok && value == "" && unset(name) {
+				report(name, s.From.Line)
+			}
+		}
+
+		// args declared in the stage, mapped to whether they have a value
+		args := map[string]bool{}
+		for _, inst := range s.Instructions {
+			switch inst.Cmd {
+			case "ARG":
+				for _, arg := range inst.Args {
+					name, _, hasDefault := strings.Cut(arg, "=")
+					args[name] = hasDefault || l.globals[name] != "" || !unset(name)
+				}
+				continue
+			}
+			for _, name := range variables(inst.Text()) {
+				if set, declared := args[name]; declared && !set {
+					report(name, inst.Line)
+				}
+			}
+			if inst.Cmd == "ENV" {
+				for _, name := range envNames(inst.Args) {
+					args[name] = true
+				}
+			}
+		}
+	}
+}
+
+// variables returns the names of the variables referenced in s.
+func variables(s string) []string {
+	var names []string
+	for _, m := range variable.FindAllStringSubmatch(s, -1) {
+		names = append(names, m[1]+m[3])
+	}
+	return names
+}
+
+// envNames returns the names set by the arguments of an ENV instruction,
+// either KEY=VALUE pairs or KEY VALUE.
+func envNames(args []string) []string {
+	if len(args) > 0 && !strings.Contains(args[0], "=") {
+		return args[:1]
+	}
+	var names []string
+	for _, arg := range args {
+		if name, _, ok := strings.Cut(arg, "="); ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
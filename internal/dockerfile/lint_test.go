@@ -0,0 +1,182 @@
+package dockerfile
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/zenginechris/devx/internal/filesystem"
+)
+
+func TestLint(t *testing.T) {
+	entries := contextEntries("Dockerfile", "go.mod", "go.sum", "cmd", "cmd/main.go", "web", "web/app", "web/app/index.ts")
+	ignored := []filesystem.Ignored{
+		{Name: "docs", Dir: true, Rule: filesystem.Rule{Pattern: "docs", Origin: ".dockerignore", Line: 1}},
+		{Name: "README.md", Rule: filesystem.Rule{Pattern: "*.md", Origin: ".dockerignore", Line: 2}},
+	}
+
+	const header = "FROM golang:1.24@sha256:abc\nUSER app\n"
+
+	tests := []struct {
+		name       string
+		dockerfile string
+		args       map[string]string
+		target     string
+		disable    []string
+		// want are the findings as rule:line
+		want []string
+	}{
+		{
+			name:       "no findings",
+			dockerfile: header + "COPY go.mod go.sum ./\nCOPY cmd/ /src/cmd/\n",
+		},
+		{
+			name:       "missing source",
+			dockerfile: header + "COPY main.go /src/\n",
+			want:       []string{"missing-source:3"},
+		},
+		{
+			name:       "ignored source",
+			dockerfile: header + "COPY README.md /src/\nCOPY docs/guide.md /src/\n",
+			want:       []string{"missing-source:3", "missing-source:4"},
+		},
+		{
+			name:       "wildcard source",
+			dockerfile: header + "COPY go.* ./\nCOPY cmd/*.go ./\nCOPY *.rs ./\n",
+			want:       []string{"missing-source:5"},
+		},
+		{
+			name:       "double star source",
+			dockerfile: header + "COPY **/index.ts ./\nCOPY web/**/*.ts ./\nCOPY web/**/*.css ./\n",
+			want:       []string{"missing-source:5"},
+		},
+		{
+			name:       "ignored wildcard source",
+			dockerfile: header + "COPY *.md ./\n",
+			want:       []string{"missing-source:3"},
+		},
+		{
+			name:       "sources that are not in the context",
+			dockerfile: header + "COPY --from=builder /out /out\nADD https://example.com/a.tgz /\nCOPY <<EOF /etc/conf\nx\nEOF\nCOPY ${SRC} /\n",
+		},
+		{
+			name:       "latest tag",
+			dockerfile: "FROM alpine AS base\nFROM alpine:latest\nCOPY --from=base /bin/sh /bin/\nUSER app\n",
+			want:       []string{"latest-tag:1", "latest-tag:2"},
+		},
+		{
+			name:       "unpinned image",
+			dockerfile: "FROM alpine:3.20\nUSER app\n",
+			want:       []string{"unpinned-image:1"},
+		},
+		{
+			name:       "root user",
+			dockerfile: "FROM scratch\nUSER root\n",
+			want:       []string{"root-user:2"},
+		},
+		{
+			name:       "no user",
+			dockerfile: "FROM scratch\n",
+			want:       []string{"root-user:1"},
+		},
+		{
+			name:       "user of a base stage",
+			dockerfile: "FROM scratch AS base\nUSER app\nFROM base\n",
+		},
+		{
+			name:       "apt cleanup",
+			dockerfile: header + "RUN apt-get update && apt-get install -y curl\nRUN apt-get install -y git && rm -rf /var/lib/apt/lists/*\n",
+			want:       []string{"apt-cleanup:3"},
+		},
+		{
+			name:       "apt cache mount",
+			dockerfile: header + "RUN --mount=type=cache,target=/var/lib/apt apt-get install -y curl\n",
+		},
+		{
+			name:       "unset arg",
+			dockerfile: header + "ARG VERSION\nARG NAME=devx\nRUN echo $VERSION $NAME $TARGETARCH\n",
+			want:       []string{"unset-arg:5"},
+		},
+		{
+			name:       "passed arg",
+			dockerfile: header + "ARG VERSION\nRUN echo $VERSION\n",
+			args:       map[string]string{"VERSION": "1"},
+		},
+		{
+			name:       "unused stage",
+			dockerfile: "FROM alpine AS unused\nCOPY missing /\nFROM scratch\nUSER app\n",
+		},
+		{
+			name:       "target",
+			dockerfile: "FROM scratch AS dev\nCOPY missing /\nFROM scratch\nUSER app\n",
+			target:     "dev",
+			want:       []string{"root-user:1", "missing-source:2"},
+		},
+		{
+			name:       "disabled rule",
+			dockerfile: "FROM alpine\nCOPY missing /\n",
+			disable:    []string{RuleLatestTag, RuleMissingSource},
+			want:       []string{"root-user:1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := Parse(strings.NewReader(tt.dockerfile))
+			if err != nil {
+				t.Fatal(err)
+			}
+			findings, err := Lint(d, Options{Entries: entries, Ignored: ignored, Args: tt.args, Target: tt.target, Disable: tt.disable})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, f := range findings {
+				got = append(got, fmt.Sprintf("%s:%d", f.Rule, f.Line))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v (%+v)", got, tt.want, findings)
+			}
+		})
+	}
+}
+
+func TestLintIgnoredSourceMessage(t *testing.T) {
+	d, err := Parse(strings.NewReader("FROM scratch\nUSER app\nCOPY **/*.md /\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rule := filesystem.Rule{Pattern: "*.md", Origin: ".dockerignore", Line: 2}
+	findings, err := Lint(d, Options{
+		Entries: contextEntries("Dockerfile", "docs"),
+		Ignored: []filesystem.Ignored{{Name: "docs/guides/setup.md", Rule: rule}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 1 || !strings.Contains(findings[0].Message, rule.String()) {
+		t.Errorf("got %+v, want a finding naming the rule %s", findings, rule)
+	}
+}
+
+func TestLintUnknownRule(t *testing.T) {
+	d, err := Parse(strings.NewReader("FROM scratch\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Lint(d, Options{Disable: []string{"no-such-rule"}}); err == nil {
+		t.Error("expected an error for an unknown rule")
+	}
+}
+
+// contextEntries returns sorted entries with names.
+func contextEntries(names ...string) []filesystem.Entry {
+	slices.Sort(names)
+	entries := make([]filesystem.Entry, 0, len(names))
+	for _, name := range names {
+		entries = append(entries, filesystem.Entry{Name: name})
+	}
+	return entries
+}
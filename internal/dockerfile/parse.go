@@ -0,0 +1,215 @@
+// Package dockerfile parses Dockerfiles and checks them for common mistakes.
+package dockerfile
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+var (
+	directiveLine = regexp.MustCompile(`^#\s*([a-zA-Z]+)\s*=\s*(.*)$`)
+	heredocMarker = regexp.MustCompile(`^<<(-?)(["']?)([a-zA-Z_][a-zA-Z0-9_]*)(["']?)`)
+)
+
+// Instruction is a single instruction of a Dockerfile.
+type Instruction struct {
+	// Cmd is the upper case instruction, e.g. COPY.
+	Cmd string
+	// Flags are the leading options, e.g. --from=builder.
+	Flags []string
+	// Args are the arguments of the shell or exec (JSON) form.
+	Args []string
+	// Heredocs are the bodies of the here-documents of the instruction.
+	Heredocs []string
+	// Line is the line number the instruction starts at.
+	Line int
+}
+
+// Flag returns the value of the option --name.
+func (i Instruction) Flag(name string) (string, bool) {
+	for _, f := range i.Flags {
+		if v, ok := strings.CutPrefix(f, "--"+name+"="); ok {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// Text returns the arguments and the here-documents of the instruction.
+func (i Instruction) Text() string {
+	return strings.Join(append(slices.Clone(i.Args), i.Heredocs...), " ")
+}
+
+// Stage is a build stage, starting with a FROM instruction.
+type Stage struct {
+	// Name is the lower case name of the stage, empty if it has none.
+	Name string
+	// Base is the image or the stage the stage is built on.
+	Base string
+	From Instruction
+	// Instructions are the instructions after FROM.
+	Instructions []Instruction
+}
+
+// Dockerfile is a parsed Dockerfile.
+type Dockerfile struct {
+	// Args are the ARG instructions before the first FROM.
+	Args   []Instruction
+	Stages []Stage
+}
+
+// Parse parses a Dockerfile. It handles comments, parser directives, line
+// continuations and here-documents, but does not expand variables.
+func Parse(r io.Reader) (*Dockerfile, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	d := &Dockerfile{}
+	escape := `\`
+	directives := true
+
+	for i := 0; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if directives {
+			if m := directiveLine.FindStringSubmatch(trimmed); m != nil {
+				if strings.EqualFold(m[1], "escape") && m[2] != "" {
+					escape = m[2][:1]
+				}
+				continue
+			}
+			directives = false
+		}
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		line := i + 1
+		var text strings.Builder
+		for first := i; i < len(lines); i++ {
+			l := strings.TrimRightFunc(lines[i], unicode.IsSpace)
+			if t := strings.TrimSpace(l); i > first && (t == "" || strings.HasPrefix(t, "#")) {
+				// blank lines and comments inside a continuation are removed
+				continue
+			}
+			if s, ok := strings.CutSuffix(l, escape); ok {
+				text.WriteString(s)
+				continue
+			}
+			text.WriteString(l)
+			break
+		}
+
+		inst := parseInstruction(text.String(), line)
+		if inst.Cmd == "RUN" || inst.Cmd == "COPY" || inst.Cmd == "ADD" {
+			for _, m := range heredocMarkers(strings.Join(inst.Args, " ")) {
+				var body []string
+				for i++; i < len(lines); i++ {
+					l := lines[i]
+					if m[1] == "-" {
+						l = strings.TrimLeft(l, "\t")
+					}
+					if l == m[3] {
+						break
+					}
+					body = append(body, l)
+				}
+				inst.Heredocs = append(inst.Heredocs, strings.Join(body, "\n"))
+			}
+		}
+
+		switch {
+		case inst.Cmd == "FROM":
+			stage := Stage{From: inst}
+			if len(inst.Args) > 0 {
+				stage.Base = inst.Args[0]
+			}
+			if len(inst.Args) == 3 && strings.EqualFold(inst.Args[1], "AS") {
+				stage.Name = strings.ToLower(inst.Args[2])
+			}
+			d.Stages = append(d.Stages, stage)
+		case len(d.Stages) == 0:
+			if inst.Cmd == "ARG" {
+				d.Args = append(d.Args, inst)
+			}
+		default:
+			s := &d.Stages[len(d.Stages)-1]
+			s.Instructions = append(s.Instructions, inst)
+		}
+	}
+	return d, nil
+}
+
+// heredocMarkers returns the submatches of heredocMarker for every
+// here-document marker in text. Markers inside quoted strings and here-strings
+// (<<<) are skipped.
+func heredocMarkers(text string) [][]string {
+	var markers [][]string
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\\':
+			i++
+		case c == '"' || c == '\'':
+			quote = c
+		case strings.HasPrefix(text[i:], "<<<"):
+			i += 2
+		case strings.HasPrefix(text[i:], "<<"):
+			if m := heredocMarker.FindStringSubmatch(text[i:]); m != nil {
+				markers = append(markers, m)
+				i += len(m[0]) - 1
+			}
+		}
+	}
+	return markers
+}
+
+// parseInstruction splits an instruction into its command, flags and arguments.
+func parseInstruction(text string, line int) Instruction {
+	text = strings.TrimSpace(text)
+	cmd, rest := text, ""
+	if i := strings.IndexFunc(text, unicode.IsSpace); i >= 0 {
+		cmd, rest = text[:i], strings.TrimSpace(text[i:])
+	}
+	inst := Instruction{Cmd: strings.ToUpper(cmd), Line: line}
+
+	switch inst.Cmd {
+	case "FROM", "COPY", "ADD", "RUN":
+		for strings.HasPrefix(rest, "--") {
+			flag := rest
+			rest = ""
+			if i := strings.IndexFunc(flag, unicode.IsSpace); i >= 0 {
+				flag, rest = flag[:i], strings.TrimSpace(flag[i:])
+			}
+			inst.Flags = append(inst.Flags, flag)
+		}
+	}
+
+	if strings.HasPrefix(rest, "[") {
+		var args []string
+		if err := json.Unmarshal([]byte(rest), &args); err == nil {
+			inst.Args = args
+			return inst
+		}
+	}
+	inst.Args = strings.Fields(rest)
+	return inst
+}
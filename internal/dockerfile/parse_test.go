@@ -0,0 +1,119 @@
+package dockerfile
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name       string
+		dockerfile string
+		want       []Instruction
+	}{
+		{
+			name:       "single line",
+			dockerfile: "FROM alpine\nRUN echo hello\n",
+			want:       []Instruction{{Cmd: "RUN", Args: []string{"echo", "hello"}, Line: 2}},
+		},
+		{
+			name:       "continuation",
+			dockerfile: "FROM alpine\nRUN apk add \\\n  curl \\\n  git\nUSER app\n",
+			want: []Instruction{
+				{Cmd: "RUN", Args: []string{"apk", "add", "curl", "git"}, Line: 2},
+				{Cmd: "USER", Args: []string{"app"}, Line: 5},
+			},
+		},
+		{
+			name:       "blank line in continuation",
+			dockerfile: "FROM alpine\nRUN apk add \\\n  curl \\\n\n  git\nUSER app\n",
+			want: []Instruction{
+				{Cmd: "RUN", Args: []string{"apk", "add", "curl", "git"}, Line: 2},
+				{Cmd: "USER", Args: []string{"app"}, Line: 6},
+			},
+		},
+		{
+			name:       "comment in continuation",
+			dockerfile: "FROM alpine\nRUN apk add \\\n  # tools\n  curl \\\n   \n  # more tools\n  git\n",
+			want:       []Instruction{{Cmd: "RUN", Args: []string{"apk", "add", "curl", "git"}, Line: 2}},
+		},
+		{
+			name:       "escape directive",
+			dockerfile: "# escape=`\nFROM alpine\nRUN echo a `\n\n  b\n",
+			want:       []Instruction{{Cmd: "RUN", Args: []string{"echo", "a", "b"}, Line: 3}},
+		},
+		{
+			name:       "flags and exec form",
+			dockerfile: "FROM alpine\nCOPY --from=builder --chown=app /out [\"/app\"]\nCMD [\"/app\", \"serve\"]\n",
+			want: []Instruction{
+				{Cmd: "COPY", Flags: []string{"--from=builder", "--chown=app"}, Args: []string{"/out", `["/app"]`}, Line: 2},
+				{Cmd: "CMD", Args: []string{"/app", "serve"}, Line: 3},
+			},
+		},
+		{
+			name:       "heredoc",
+			dockerfile: "FROM alpine\nRUN <<EOF\napk add curl\n\nEOF\nUSER app\n",
+			want: []Instruction{
+				{Cmd: "RUN", Args: []string{"<<EOF"}, Heredocs: []string{"apk add curl\n"}, Line: 2},
+				{Cmd: "USER", Args: []string{"app"}, Line: 6},
+			},
+		},
+		{
+			name:       "quoted heredoc marker",
+			dockerfile: "FROM alpine\nRUN <<'EOF' sh\necho $HOME\nEOF\n",
+			want:       []Instruction{{Cmd: "RUN", Args: []string{"<<'EOF'", "sh"}, Heredocs: []string{"echo $HOME"}, Line: 2}},
+		},
+		{
+			name:       "heredoc marker in quotes",
+			dockerfile: "FROM alpine\nRUN echo \"a<<b\" 'c <<EOF'\nUSER app\n",
+			want: []Instruction{
+				{Cmd: "RUN", Args: []string{"echo", `"a<<b"`, "'c", "<<EOF'"}, Line: 2},
+				{Cmd: "USER", Args: []string{"app"}, Line: 3},
+			},
+		},
+		{
+			name:       "here-string",
+			dockerfile: "FROM alpine\nRUN cat <<<EOF\nUSER app\n",
+			want: []Instruction{
+				{Cmd: "RUN", Args: []string{"cat", "<<<EOF"}, Line: 2},
+				{Cmd: "USER", Args: []string{"app"}, Line: 3},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := Parse(strings.NewReader(tt.dockerfile))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(d.Stages) != 1 {
+				t.Fatalf("got %d stages, want 1", len(d.Stages))
+			}
+			if got := d.Stages[0].Instructions; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseStages(t *testing.T) {
+	d, err := Parse(strings.NewReader("ARG VERSION=1\nFROM golang:1.24 AS Builder\nRUN go build\nFROM builder\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(d.Args) != 1 || d.Args[0].Args[0] != "VERSION=1" {
+		t.Errorf("got args %+v, want VERSION=1", d.Args)
+	}
+	if len(d.Stages) != 2 {
+		t.Fatalf("got %d stages, want 2", len(d.Stages))
+	}
+	if s := d.Stages[0]; s.Name != "builder" || s.Base != "golang:1.24" || len(s.Instructions) != 1 {
+		t.Errorf("got first stage %+v, want builder from golang:1.24 with one instruction", s)
+	}
+	if s := d.Stages[1]; s.Name != "" || s.Base != "builder" {
+		t.Errorf("got second stage %+v, want an unnamed stage from builder", s)
+	}
+}
@@ -0,0 +1,292 @@
+package filesystem
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ContextSource is a file or directory that is added to a build context.
+type ContextSource struct {
+	// Path is the file or directory on disk.
+	Path string
+	// Dest is the slash separated destination inside the build context.
+	// It defaults to the base name of Path, a leading slash is ignored.
+	Dest string
+	// IgnoreFile overrides the .dockerignore file of a directory source.
+	IgnoreFile string
+	// IgnoreFiles are additional .dockerignore formatted files of a directory source.
+	IgnoreFiles []string
+	// Ignore are additional .dockerignore patterns of a directory source.
+	Ignore []string
+	// Gitignore enables the .gitignore files of a directory source, together
+	// with .git/info/exclude and the user's global git excludes.
+	Gitignore bool
+	// FollowSymlinks adds the targets of symlinks instead of the links.
+	FollowSymlinks bool
+}
+
+func (s ContextSource) dest() string {
+	if s.Dest != "" {
+		// an absolute destination is relative to the root of the context
+		if dest := strings.TrimPrefix(path.Clean(filepath.ToSlash(s.Dest)), "/"); dest != "" {
+			return dest
+		}
+		return "."
+	}
+	return filepath.Base(s.Path)
+}
+
+// Entry is a single directory, file or symlink of an assembled build context.
+type Entry struct {
+	// Name is the slash separated path inside the build context.
+	Name string
+	// Source is the path on disk. It is empty for implicit parent directories.
+	Source   string
+	Mode     fs.FileMode
+	Size     int64
+	ModTime  time.Time
+	Linkname string
+}
+
+// IsDir reports whether the entry is a directory.
+func (e Entry) IsDir() bool { return e.Mode.IsDir() }
+
+// entryPath returns the path of e below dir. Entries are sorted by name, so
+// creating them in order creates parent directories before their content.
+func entryPath(dir string, e Entry) string {
+	return filepath.Join(dir, filepath.FromSlash(e.Name))
+}
+
+// Ignored is a path that was excluded from the build context.
+type Ignored struct {
+	// Name is the slash separated path the entry would have in the build context.
+	Name string
+	Dir  bool
+	// Rule is the rule that excluded the path.
+	Rule Rule
+}
+
+// epoch is the modification time of every entry in a context tar stream.
+var epoch = time.Unix(0, 0).UTC()
+
+// ContextAssembler assembles a build context from several sources without
+// copying them. Directory sources honor their .dockerignore file.
+type ContextAssembler struct {
+	sources []ContextSource
+}
+
+// NewContextAssembler creates a new assembler for the given sources.
+func NewContextAssembler(sources ...ContextSource) *ContextAssembler {
+	return &ContextAssembler{sources: sources}
+}
+
+// Entries walks all sources and returns the entries of the build context sorted by name.
+func (a *ContextAssembler) Entries() ([]Entry, error) {
+	entries, _, err := a.Assemble()
+	return entries, err
+}
+
+// Ignored walks all sources and returns the paths excluded by ignore rules.
+// Content of an ignored directory is not listed separately.
+func (a *ContextAssembler) Ignored() ([]Ignored, error) {
+	_, ignored, err := a.Assemble()
+	return ignored, err
+}
+
+// Assemble walks all sources and returns both the entries of the build
+// context and the ignored paths, sorted by name.
+func (a *ContextAssembler) Assemble() ([]Entry, []Ignored, error) {
+	if err := a.checkDestinations(); err != nil {
+		return nil, nil, err
+	}
+
+	entries := map[string]Entry{}
+	var ignored []Ignored
+
+	for _, source := range a.sources {
+		if err := a.walkSource(source, entries, &ignored); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// make sure every parent directory is part of the context
+	for name, e := range entries {
+		for dir := path.Dir(name); dir != "."; dir = path.Dir(dir) {
+			parent, ok := entries[dir]
+			if !ok {
+				entries[dir] = Entry{Name: dir, Mode: fs.ModeDir | 0755, ModTime: epoch}
+			} else if !parent.IsDir() {
+				return nil, nil, fmt.Errorf("%s and %s are both placed at '%s' in the build context, set a distinct dest for one of them", parent.Source, e.Source, dir)
+			}
+		}
+	}
+
+	sorted := make([]Entry, 0, len(entries))
+	for _, e := range entries {
+		sorted = append(sorted, e)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	sort.Slice(ignored, func(i, j int) bool { return ignored[i].Name < ignored[j].Name })
+
+	return sorted, ignored, nil
+}
+
+// checkDestinations makes sure every source has a destination inside the
+// build context. Sources may share a destination as long as their files
+// don't collide, which addEntry reports.
+func (a *ContextAssembler) checkDestinations() error {
+	for _, source := range a.sources {
+		if dest := source.dest(); dest == ".." || strings.HasPrefix(dest, "../") {
+			return fmt.Errorf("destination '%s' of %s is outside of the build context", source.Dest, source.Path)
+		}
+	}
+	return nil
+}
+
+// addEntry adds e to entries. Directories of different sources are merged,
+// any other collision is an error.
+func addEntry(entries map[string]Entry, e Entry) error {
+	if existing, ok := entries[e.Name]; ok && !(existing.IsDir() && e.IsDir()) {
+		return fmt.Errorf("%s and %s are both placed at '%s' in the build context, set a distinct dest for one of them", existing.Source, e.Source, e.Name)
+	}
+	entries[e.Name] = e
+	return nil
+}
+
+func (a *ContextAssembler) walkSource(source ContextSource, entries map[string]Entry, ignored *[]Ignored) error {
+	sourceInfo, err := os.Stat(source.Path)
+	if err != nil {
+		return fmt.Errorf("error getting source info: %w", err)
+	}
+
+	dest := source.dest()
+
+	if !sourceInfo.IsDir() {
+		if dest == "." {
+			return fmt.Errorf("file %s can't be placed at the root of the build context, set a dest with a file name", source.Path)
+		}
+		return addEntry(entries, newEntry(dest, source.Path, sourceInfo, ""))
+	}
+
+	matcher, err := source.matcher()
+	if err != nil {
+		return err
+	}
+
+	w := &sourceWalker{
+		source:  source,
+		dest:    dest,
+		matcher: matcher,
+		entries: entries,
+		ignored: ignored,
+	}
+
+	// the root of a source placed at the root of the context has no entry of its own
+	if dest != "." {
+		if err := addEntry(entries, newEntry(dest, source.Path, sourceInfo, "")); err != nil {
+			return err
+		}
+	}
+
+	realRoot, err := filepath.EvalSymlinks(source.Path)
+	if err != nil {
+		return fmt.Errorf("error resolving %s: %w", source.Path, err)
+	}
+	return w.walk(source.Path, ".", []string{realRoot})
+}
+
+func newEntry(name, source string, info fs.FileInfo, linkname string) Entry {
+	e := Entry{
+		Name:     name,
+		Source:   source,
+		Mode:     info.Mode(),
+		ModTime:  info.ModTime(),
+		Linkname: linkname,
+	}
+	if info.Mode().IsRegular() {
+		e.Size = info.Size()
+	}
+	return e
+}
+
+// WriteTar writes the assembled entries as a reproducible tar stream to w.
+// Entries must be sorted by name, ownership and modification times are normalized.
+func WriteTar(w io.Writer, entries []Entry) error {
+	tw := tar.NewWriter(w)
+	for _, e := range entries {
+		if err := writeTarEntry(tw, e); err != nil {
+			return fmt.Errorf("error writing %s to context: %w", e.Name, err)
+		}
+	}
+	return tw.Close()
+}
+
+func writeTarEntry(tw *tar.Writer, e Entry) error {
+	hdr := &tar.Header{
+		Name:    e.Name,
+		Mode:    int64(e.Mode.Perm()),
+		ModTime: epoch,
+		Format:  tar.FormatPAX,
+	}
+
+	switch {
+	case e.IsDir():
+		hdr.Typeflag = tar.TypeDir
+		hdr.Name += "/"
+	case e.Mode&fs.ModeSymlink != 0:
+		hdr.Typeflag = tar.TypeSymlink
+		hdr.Linkname = e.Linkname
+	case e.Mode.IsRegular():
+		hdr.Typeflag = tar.TypeReg
+		hdr.Size = e.Size
+	default:
+		// sockets, devices and pipes have no place in a build context
+		return nil
+	}
+
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	if hdr.Typeflag != tar.TypeReg {
+		return nil
+	}
+
+	f, err := os.Open(e.Source)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// the size is fixed by the header, a file growing while copying must not break the stream
+	n, err := io.Copy(tw, io.LimitReader(f, e.Size))
+	if err != nil {
+		return err
+	}
+	if n != e.Size {
+		return fmt.Errorf("file changed while reading: expected %d bytes, got %d", e.Size, n)
+	}
+	return nil
+}
+
+// HasEntry reports whether entries contain an entry with the given name.
+func HasEntry(entries []Entry, name string) bool {
+	_, ok := FindEntry(entries, name)
+	return ok
+}
+
+// FindEntry returns the entry with the given name of the sorted entries.
+func FindEntry(entries []Entry, name string) (Entry, bool) {
+	i := sort.Search(len(entries), func(i int) bool { return entries[i].Name >= name })
+	if i < len(entries) && entries[i].Name == name {
+		return entries[i], true
+	}
+	return Entry{}, false
+}
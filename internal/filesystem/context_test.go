@@ -0,0 +1,82 @@
+package filesystem
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestAssembleDestinations(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"app/main.go", "app/lib/a.go", "shared/lib/b.go", "shared/README.md", "other/README.md", "proto/api.proto", "file.txt"} {
+		writeFile(t, filepath.Join(dir, name), name)
+	}
+	src := func(name, dest string) ContextSource {
+		return ContextSource{Path: filepath.Join(dir, name), Dest: dest}
+	}
+
+	tests := []struct {
+		name    string
+		sources []ContextSource
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:    "sources sharing the root",
+			sources: []ContextSource{src("app", "."), src("shared", ".")},
+			want:    []string{"README.md", "lib", "lib/a.go", "lib/b.go", "main.go"},
+		},
+		{
+			name:    "absolute destinations",
+			sources: []ContextSource{src("proto", "/libs/proto"), src("file.txt", "/file.txt")},
+			want:    []string{"file.txt", "libs", "libs/proto", "libs/proto/api.proto"},
+		},
+		{
+			name:    "root destination",
+			sources: []ContextSource{src("proto", "/")},
+			want:    []string{"api.proto"},
+		},
+		{
+			name:    "file collision",
+			sources: []ContextSource{src("shared", "."), src("other", ".")},
+			wantErr: true,
+		},
+		{
+			name:    "same destination",
+			sources: []ContextSource{src("shared", "libs"), src("other", "libs")},
+			wantErr: true,
+		},
+		{
+			name:    "file below a file",
+			sources: []ContextSource{src("file.txt", "lib"), src("proto", "lib/proto")},
+			wantErr: true,
+		},
+		{
+			name:    "file below a file in reverse order",
+			sources: []ContextSource{src("proto", "lib/proto"), src("file.txt", "lib")},
+			wantErr: true,
+		},
+		{
+			name:    "file at the root",
+			sources: []ContextSource{src("file.txt", "/")},
+			wantErr: true,
+		},
+		{
+			name:    "outside of the context",
+			sources: []ContextSource{src("proto", "../proto")},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := NewContextAssembler(tt.sources...).Entries()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if got := entryNames(entries); !tt.wantErr && !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,187 +1,177 @@
 package filesystem
 
 import (
-	"bufio"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
-	"path/filepath"
-	"regexp"
-	"slices"
+	"runtime"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
 )
 
-// PatternMatcher handles .dockerignore pattern matching
-type PatternMatcher struct {
-	patterns []pattern
-}
+// progressInterval is the interval of progress updates while copying.
+const progressInterval = 2 * time.Second
 
-// pattern represents a single .dockerignore pattern
-type pattern struct {
-	val       string
-	isNegated bool
-	regex     *regexp.Regexp
+// CopyStats summarizes a copy of a build context.
+type CopyStats struct {
+	Files    int
+	Bytes    int64
+	Duration time.Duration
 }
 
-// NewPatternMatcher creates a new pattern matcher from a list of .dockerignore patterns
-func NewPatternMatcher(patterns []string) *PatternMatcher {
-	pm := &PatternMatcher{
-		patterns: make([]pattern, 0, len(patterns)),
-	}
-
-	for _, p := range patterns {
-		isNegated := false
-		if strings.HasPrefix(p, "!") {
-			isNegated = true
-			p = p[1:]
-		}
-
-		// Convert pattern to regex
-		regexPattern := patternToRegex(p)
-		regex, err := regexp.Compile("^" + regexPattern + "$")
-		if err != nil {
-			// Skip invalid patterns
-			fmt.Printf("Warning: Invalid pattern %s: %v\n", p, err)
-			continue
-		}
-
-		pm.patterns = append(pm.patterns, pattern{
-			val:       p,
-			isNegated: isNegated,
-			regex:     regex,
-		})
-	}
-
-	return pm
+// Copier copies build context entries into a directory.
+// Symlinks are copied as links, modes and modification times are kept and the
+// source is never modified.
+type Copier struct {
+	// Workers is the number of concurrent file copies, defaults to the number of CPUs.
+	Workers int
+	// Log receives per-file output at trace level and progress updates.
+	Log *logrus.Entry
 }
 
-func patternToRegex(pattern string) string {
-	// First, escape regex special chars except those we're using for patterns
-	escapedPattern := regexp.QuoteMeta(pattern)
-
-	// Restore pattern special chars that we escaped with QuoteMeta
-	escapedPattern = strings.ReplaceAll(escapedPattern, "\\*", "*")
-	escapedPattern = strings.ReplaceAll(escapedPattern, "\\?", "?")
-
-	// Create a result buffer
-	var result strings.Builder
-
-	// Process the pattern in a single pass
-	i := 0
-	for i < len(escapedPattern) {
-		if i+1 < len(escapedPattern) && escapedPattern[i:i+2] == "**" {
-			// Handle double asterisk (match zero or more path segments)
-			result.WriteString(".*")
-			i += 2
-		} else if escapedPattern[i] == '*' {
-			// Handle single asterisk (match anything except slashes)
-			result.WriteString("[^/]*")
-			i++
-		} else if escapedPattern[i] == '?' {
-			// Handle question mark (match any single character except slashes)
-			result.WriteString("[^/]")
-			i++
-		} else {
-			// Copy character as is
-			result.WriteByte(escapedPattern[i])
-			i++
-		}
-	}
+// Copy copies entries into dir.
+func (c Copier) Copy(entries []Entry, dir string) (CopyStats, error) {
+	start := time.Now()
 
-	// Handle trailing slashes to match directories and their contents
-	pattern = result.String()
-	if strings.HasSuffix(pattern, "/") {
-		pattern = pattern + ".*"
+	log := c.Log
+	if log == nil {
+		log = logrus.NewEntry(logrus.StandardLogger())
 	}
 
-	return pattern
-}
-
-func (pm *PatternMatcher) Matches(path string) bool {
-	// Last match determines the outcome
-	ignored := false
-
-	// Normalize path
-	path = filepath.ToSlash(path)
-
-	// Also check parent directories for matches
-	// This handles cases like "dir/" which should match "dir/subdir/file.txt"
-	pathSegments := strings.Split(path, "/")
-	var pathsToCheck []string
+	var dirs, files []Entry
 
-	// Add the full path
-	pathsToCheck = append(pathsToCheck, path)
+	for _, e := range entries {
+		dest := entryPath(dir, e)
 
-	// Add each parent directory path
-	for i := len(pathSegments) - 1; i > 0; i-- {
-		parentPath := strings.Join(pathSegments[:i], "/")
-		pathsToCheck = append(pathsToCheck, parentPath)
-	}
-
-	for _, pattern := range pm.patterns {
-		// Check the actual path
-		matched := pattern.regex.MatchString(path)
+		switch {
+		case e.IsDir():
+			// keep the directory writable until its content is copied
+			if err := os.MkdirAll(dest, 0700); err != nil {
+				return CopyStats{}, fmt.Errorf("error creating destination directory: %w", err)
+			}
+			dirs = append(dirs, e)
 
-		// If not matched directly, check if any parent directory is matched
-		// by the pattern (for directory exclusion patterns)
-		if !matched && strings.HasSuffix(pattern.val, "/") {
-			if slices.ContainsFunc(pathsToCheck, pattern.regex.MatchString) {
-				matched = true
+		case e.Mode&fs.ModeSymlink != 0:
+			log.Tracef("Linking %s to %s", dest, e.Linkname)
+			if err := os.Symlink(e.Linkname, dest); err != nil {
+				return CopyStats{}, fmt.Errorf("error creating symlink: %w", err)
 			}
-		}
 
-		if matched {
-			ignored = !pattern.isNegated
+		case e.Mode.IsRegular():
+			files = append(files, e)
 		}
 	}
 
-	return ignored
-}
-
-func CopyToTemp(source, tempDir string) error {
-	sourceInfo, err := os.Stat(source)
+	stats, err := c.copyFiles(log, files, dir)
 	if err != nil {
-		return fmt.Errorf("error getting source info: %w", err)
+		return stats, err
 	}
 
-	// Get the base name of the source
-	baseName := filepath.Base(source)
-	destPath := filepath.Join(tempDir, baseName)
+	// children change the modification time of their directory, so directories are finished last
+	for i := len(dirs) - 1; i >= 0; i-- {
+		e := dirs[i]
+		dest := entryPath(dir, e)
+		if err := os.Chmod(dest, e.Mode.Perm()); err != nil {
+			return stats, err
+		}
+		if err := os.Chtimes(dest, e.ModTime, e.ModTime); err != nil {
+			return stats, err
+		}
+	}
 
-	// Handle directory copy
-	if sourceInfo.IsDir() {
+	stats.Duration = time.Since(start)
+	return stats, nil
+}
 
-		patterns, err := parseDockerignore(source)
-		if err != nil && !os.IsNotExist(err) {
-			return fmt.Errorf("error parsing .dockerignore: %w", err)
+// copyFiles copies regular files using a bounded number of workers.
+func (c Copier) copyFiles(log *logrus.Entry, files []Entry, dir string) (CopyStats, error) {
+	workers := c.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	var total int64
+	for _, e := range files {
+		total += e.Size
+	}
+
+	var (
+		copied, copiedBytes atomic.Int64
+		failed              atomic.Bool
+		errs                []error
+		mu                  sync.Mutex
+		wg                  sync.WaitGroup
+	)
+
+	jobs := make(chan Entry)
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for e := range jobs {
+				dest := entryPath(dir, e)
+				log.Tracef("Copying %s to %s", e.Source, dest)
+
+				if err := copyFile(e, dest); err != nil {
+					failed.Store(true)
+					mu.Lock()
+					errs = append(errs, fmt.Errorf("error copying %s: %w", e.Name, err))
+					mu.Unlock()
+					continue
+				}
+				copied.Add(1)
+				copiedBytes.Add(e.Size)
+			}
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(progressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				log.Infof("Copied %d/%d files (%s/%s)", copied.Load(), len(files),
+					FormatBytes(copiedBytes.Load()), FormatBytes(total))
+			}
 		}
+	}()
 
-		var matcher *PatternMatcher
-		if len(patterns) > 0 {
-			matcher = NewPatternMatcher(patterns)
+	for _, e := range files {
+		if failed.Load() {
+			break
 		}
-
-		// replace
-		return copyDirWithDockerignore(source, destPath, matcher)
+		jobs <- e
 	}
+	close(jobs)
+	wg.Wait()
+	close(done)
 
-	// Handle file copy
-	return copyFile(source, destPath)
+	stats := CopyStats{Files: int(copied.Load()), Bytes: copiedBytes.Load()}
+	return stats, errors.Join(errs...)
 }
 
-// copyFile copies a single file from src to dst
-func copyFile(src, dst string) error {
-	fmt.Printf("Copying file %s to %s\n", src, dst)
-
+// copyFile copies a single file entry to dst, keeping its mode and modification time.
+func copyFile(e Entry, dst string) error {
 	// Open source file
-	sourceFile, err := os.Open(src)
+	sourceFile, err := os.Open(e.Source)
 	if err != nil {
 		return fmt.Errorf("error opening source file: %w", err)
 	}
 	defer sourceFile.Close()
 
 	// Create destination file
-	destFile, err := os.Create(dst)
+	destFile, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
 	if err != nil {
 		return fmt.Errorf("error creating destination file: %w", err)
 	}
@@ -194,109 +184,52 @@ func copyFile(src, dst string) error {
 	}
 
 	// Copy file permissions from source to destination
-	sourceInfo, err := os.Stat(src)
-	if err != nil {
-		return fmt.Errorf("error getting source file info: %w", err)
+	if err := destFile.Chmod(e.Mode.Perm()); err != nil {
+		return fmt.Errorf("error setting file mode: %w", err)
+	}
+	if err := destFile.Close(); err != nil {
+		return fmt.Errorf("error closing destination file: %w", err)
 	}
 
-	return os.Chmod(dst, sourceInfo.Mode())
+	return os.Chtimes(dst, e.ModTime, e.ModTime)
 }
 
-func copyDirWithDockerignore(src, dst string, matcher *PatternMatcher) error {
-	fmt.Printf("Copying directory %s to %s (with .dockerignore support)\n", src, dst)
-
-	// Create the destination directory
-	sourceInfo, err := os.Stat(src)
-	if err != nil {
-		return fmt.Errorf("error getting source directory info: %w", err)
+// FormatBytes formats a byte count in human readable form.
+func FormatBytes(b int64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
 	}
-
-	err = os.MkdirAll(dst, sourceInfo.Mode())
-	if err != nil {
-		return fmt.Errorf("error creating destination directory: %w", err)
+	div, exp := int64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
 	}
-
-	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		if path == src {
-			return nil
-		}
-
-		// Calculate the relative path from source
-		relPath, err := filepath.Rel(src, path)
-		if err != nil {
-			return fmt.Errorf("error calculating relative path: %w", err)
-		}
-
-		// Check if file should be ignored (if we have a matcher)
-		if matcher != nil {
-			if matcher.Matches(relPath) {
-				fmt.Printf("Ignoring %s (matched .dockerignore rule)\n", relPath)
-				if info.IsDir() {
-					return filepath.SkipDir
-				}
-				return nil
-			}
-		}
-
-		// Calculate the destination path
-		destPath := filepath.Join(dst, relPath)
-
-		// Handle directories and files
-		if info.IsDir() {
-			if err := os.Chmod(destPath, info.Mode()); err != nil {
-				return err
-			}
-			return os.MkdirAll(destPath, info.Mode())
-		} else {
-			// For files, handle any dot files (hidden files) specially
-			baseName := filepath.Base(path)
-			if len(baseName) > 0 && baseName[0] == '.' {
-				fmt.Printf("Copying hidden file: %s\n", relPath)
-			}
-			os.Chmod(path, 0644)
-			return copyFile(path, destPath)
-		}
-	})
+	return fmt.Sprintf("%.1f %ciB", float64(b)/float64(div), "KMGTPE"[exp])
 }
 
-// parseDockerignore reads .dockerignore file and returns patterns
-func parseDockerignore(dir string) ([]string, error) {
-	dockerignorePath := filepath.Join(dir, ".dockerignore")
-
-	file, err := os.Open(dockerignorePath)
-	if err != nil {
-		return nil, err
+// ParseBytes parses a size like 512MB or 10GiB. Units are powers of 1024,
+// matching FormatBytes.
+func ParseBytes(s string) (int64, error) {
+	v := strings.TrimSpace(s)
+	i := strings.IndexFunc(v, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	num, unit := v, ""
+	if i >= 0 {
+		num, unit = v[:i], strings.ToUpper(strings.TrimSpace(v[i:]))
 	}
-	defer file.Close()
-
-	var patterns []string
-	scanner := bufio.NewScanner(file)
 
-	for scanner.Scan() {
-		pattern := strings.TrimSpace(scanner.Text())
-
-		// Skip comments and empty lines
-		if pattern == "" || strings.HasPrefix(pattern, "#") {
-			continue
-		}
-
-		// Handle negated patterns (those starting with !)
-		if strings.HasPrefix(pattern, "!") {
-			pattern = "!" + filepath.ToSlash(pattern[1:])
-		} else {
-			pattern = filepath.ToSlash(pattern)
-		}
-
-		patterns = append(patterns, pattern)
+	n, err := strconv.ParseFloat(num, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size '%s'", s)
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, err
+	if unit == "" || unit == "B" {
+		return int64(n), nil
 	}
-
-	return patterns, nil
+	prefix := strings.TrimSuffix(strings.TrimSuffix(unit, "B"), "I")
+	exp := strings.Index("KMGTPE", prefix)
+	if len(prefix) != 1 || exp < 0 {
+		return 0, fmt.Errorf("invalid size unit '%s'", unit)
+	}
+	return int64(n * float64(int64(1)<<(10*(exp+1)))), nil
 }
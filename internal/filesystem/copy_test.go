@@ -0,0 +1,83 @@
+package filesystem
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCopierKeepsModeAndModTime(t *testing.T) {
+	src := sourceTree(t)
+	entries := assemble(t, ContextSource{Path: src, Dest: "."})
+
+	dir := t.TempDir()
+	stats, err := Copier{Workers: 2}.Copy(entries, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Files != 2 {
+		t.Errorf("got %d files copied, want 2", stats.Files)
+	}
+
+	checkTree(t, dir, entries, true)
+}
+
+// sourceTree creates a build context with files, directories and a symlink
+// that have modes and modification times differing from the defaults.
+func sourceTree(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "run.sh"), "#!/bin/sh\n")
+	writeFile(t, filepath.Join(dir, "sub", "a.txt"), "a")
+	symlink(t, "sub/a.txt", filepath.Join(dir, "link"))
+
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	for name, mode := range map[string]fs.FileMode{"run.sh": 0750, "sub/a.txt": 0600, "sub": 0750} {
+		p := filepath.Join(dir, name)
+		if err := os.Chmod(p, mode); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(p, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// checkTree checks that dir holds entries with their content, modes and
+// modification times. Directory times are only checked if dirTimes is set.
+func checkTree(t *testing.T, dir string, entries []Entry, dirTimes bool) {
+	t.Helper()
+	for _, e := range entries {
+		info, err := os.Lstat(entryPath(dir, e))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if info.Mode() != e.Mode {
+			t.Errorf("%s: got mode %v, want %v", e.Name, info.Mode(), e.Mode)
+		}
+
+		switch {
+		case e.Mode&fs.ModeSymlink != 0:
+			if target, err := os.Readlink(entryPath(dir, e)); err != nil || target != e.Linkname {
+				t.Errorf("%s: got link to %q (%v), want %q", e.Name, target, err, e.Linkname)
+			}
+			continue
+		case e.Mode.IsRegular():
+			same, err := sameContent(e.Source, entryPath(dir, e))
+			if err != nil || !same {
+				t.Errorf("%s: content differs from %s (%v)", e.Name, e.Source, err)
+			}
+		case !dirTimes:
+			continue
+		}
+
+		if !info.ModTime().Equal(e.ModTime) {
+			t.Errorf("%s: got modification time %v, want %v", e.Name, info.ModTime(), e.ModTime)
+		}
+	}
+}
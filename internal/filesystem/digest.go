@@ -0,0 +1,31 @@
+package filesystem
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// Digest computes a deterministic sha256 digest of the assembled build context.
+// See Manifest.Digest.
+func (a *ContextAssembler) Digest(extra ...string) (string, error) {
+	m, err := a.Manifest()
+	if err != nil {
+		return "", err
+	}
+	return m.Digest(extra...), nil
+}
+
+// hashFile writes the contents of the file at path to h.
+func hashFile(h io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("error opening file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(h, f); err != nil {
+		return fmt.Errorf("error hashing file contents: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package filesystem
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Rule is an ignore pattern together with where it was defined.
+type Rule struct {
+	Pattern string
+	// Origin is the file the pattern was read from, or a description of
+	// the setting that defined it.
+	Origin string
+	// Line is the line number in Origin, zero if not read from a file.
+	Line int
+	// DirOnly restricts the rule to directories, like a .gitignore pattern
+	// with a trailing slash.
+	DirOnly bool
+}
+
+// String returns the rule prefixed with its origin.
+func (r Rule) String() string {
+	p := r.Pattern
+	if r.DirOnly {
+		p += "/"
+	}
+	switch {
+	case r.Origin == "":
+		return fmt.Sprintf("%q", p)
+	case r.Line == 0:
+		return fmt.Sprintf("%s: %q", r.Origin, p)
+	default:
+		return fmt.Sprintf("%s:%d: %q", r.Origin, r.Line, p)
+	}
+}
+
+// DockerignoreFile returns the ignore file that applies to a context directory.
+// A `<Dockerfile>.dockerignore` next to the Dockerfile takes precedence over the
+// .dockerignore in the root of the context.
+func DockerignoreFile(contextDir, dockerfile string) string {
+	if dockerfile != "" {
+		specific := dockerfile + ".dockerignore"
+		if _, err := os.Stat(specific); err == nil {
+			return specific
+		}
+	}
+	return filepath.Join(contextDir, ".dockerignore")
+}
+
+// ReadIgnoreFile reads the patterns of a .dockerignore formatted file.
+// Like Docker it skips comments and empty lines, removes a leading UTF-8 BOM
+// and cleans the patterns, making absolute patterns relative to the context.
+func ReadIgnoreFile(file string) ([]string, error) {
+	rules, err := readIgnoreRules(file)
+	if err != nil {
+		return nil, err
+	}
+
+	patterns := make([]string, 0, len(rules))
+	for _, r := range rules {
+		patterns = append(patterns, r.Pattern)
+	}
+	return patterns, nil
+}
+
+// readIgnoreRules reads the rules of a .dockerignore formatted file.
+func readIgnoreRules(file string) ([]Rule, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var rules []Rule
+	scanner := bufio.NewScanner(f)
+
+	for line := 1; scanner.Scan(); line++ {
+		b := scanner.Bytes()
+		if line == 1 {
+			b = bytes.TrimPrefix(b, []byte{0xEF, 0xBB, 0xBF})
+		}
+
+		// Lines starting with # are comments, even when indented patterns are trimmed
+		p := string(b)
+		if strings.HasPrefix(p, "#") {
+			continue
+		}
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+
+		rules = append(rules, Rule{Pattern: cleanIgnorePattern(p), Origin: file, Line: line})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// cleanIgnorePattern cleans a .dockerignore pattern and makes absolute
+// patterns relative to the context, taking care of the `!` prefix.
+func cleanIgnorePattern(p string) string {
+	negated := strings.HasPrefix(p, "!")
+	if negated {
+		p = strings.TrimSpace(p[1:])
+	}
+	if p != "" {
+		p = path.Clean(filepath.ToSlash(p))
+		if len(p) > 1 && p[0] == '/' {
+			p = p[1:]
+		}
+	}
+	if negated {
+		p = "!" + p
+	}
+	return p
+}
@@ -0,0 +1,144 @@
+package filesystem
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// readGitignoreRules reads a .gitignore formatted file located in relDir,
+// relative to the root of a context source, and converts its patterns into
+// rules with .dockerignore semantics.
+func readGitignoreRules(file, relDir string) ([]Rule, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var rules []Rule
+	scanner := bufio.NewScanner(f)
+
+	for line := 1; scanner.Scan(); line++ {
+		p, dirOnly, ok := gitignorePattern(scanner.Text(), relDir)
+		if !ok {
+			continue
+		}
+		rules = append(rules, Rule{Pattern: p, Origin: file, Line: line, DirOnly: dirOnly})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// gitignorePattern converts a single .gitignore line into a .dockerignore pattern.
+// Patterns without a slash match at any depth below relDir, all other patterns
+// are anchored to relDir. dirOnly reports a trailing slash, which restricts
+// the pattern to directories. It returns false for comments and blank lines.
+func gitignorePattern(line, relDir string) (pattern string, dirOnly, ok bool) {
+	line = strings.TrimSuffix(line, "\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", false, false
+	}
+
+	// trailing spaces are ignored unless they are escaped
+	trimmed := strings.TrimRight(line, " ")
+	if strings.HasSuffix(trimmed, `\`) && len(trimmed) < len(line) {
+		trimmed += " "
+	}
+	line = trimmed
+	if line == "" {
+		return "", false, false
+	}
+
+	negated := strings.HasPrefix(line, "!")
+	if negated {
+		line = line[1:]
+	}
+
+	dirOnly = strings.HasSuffix(line, "/")
+	line = strings.TrimRight(line, "/")
+	if line == "" {
+		return "", false, false
+	}
+
+	if strings.Contains(line, "/") {
+		line = strings.TrimPrefix(line, "/")
+	} else {
+		line = "**/" + line
+	}
+
+	if relDir != "" && relDir != "." {
+		line = path.Join(relDir, line)
+	}
+
+	if negated {
+		line = "!" + line
+	}
+	return line, dirOnly, true
+}
+
+// gitWorkTree returns the root of the git work tree containing dir, the slash
+// separated path of dir relative to it and the common git directory holding
+// info/exclude. ok is false if dir is not inside a work tree or git is not installed.
+func gitWorkTree(dir string) (root, rel, gitDir string, ok bool) {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "--show-toplevel", "--git-common-dir").Output()
+	if err != nil {
+		return "", "", "", false
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(lines) != 2 {
+		return "", "", "", false
+	}
+
+	root, gitDir = lines[0], lines[1]
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(dir, gitDir)
+	}
+
+	// git reports the resolved path of the work tree
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", "", "", false
+	}
+	realDir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", "", "", false
+	}
+	rel, err = filepath.Rel(realRoot, realDir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", "", "", false
+	}
+	return realRoot, filepath.ToSlash(rel), gitDir, true
+}
+
+// gitGlobalExcludesFile returns the user's global git excludes file.
+// It is read from `core.excludesFile` and defaults to $XDG_CONFIG_HOME/git/ignore.
+// The result is cached, as it is needed for every source.
+var gitGlobalExcludesFile = sync.OnceValue(func() string {
+	out, err := exec.Command("git", "config", "--get", "core.excludesFile").Output()
+	if file := strings.TrimSpace(string(out)); err == nil && file != "" {
+		if rest, ok := strings.CutPrefix(file, "~/"); ok {
+			if home, err := os.UserHomeDir(); err == nil {
+				file = filepath.Join(home, rest)
+			}
+		}
+		return file
+	}
+
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "git", "ignore")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "git", "ignore")
+})
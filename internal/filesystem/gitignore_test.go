@@ -0,0 +1,124 @@
+package filesystem
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestGitignorePattern(t *testing.T) {
+	tests := []struct {
+		line    string
+		relDir  string
+		want    string
+		dirOnly bool
+		ok      bool
+	}{
+		{"# comment", "", "", false, false},
+		{"", "", "", false, false},
+		{"   ", "", "", false, false},
+		{"/", "", "", false, false},
+		{"foo", "", "**/foo", false, true},
+		{"foo", "sub", "sub/**/foo", false, true},
+		{"/foo", "", "foo", false, true},
+		{"/foo", "sub", "sub/foo", false, true},
+		{"a/b", "", "a/b", false, true},
+		{"a/**/b", "sub", "sub/a/**/b", false, true},
+		{"foo/", "", "**/foo", true, true},
+		{"a/foo/", "sub", "sub/a/foo", true, true},
+		{"!foo", "", "!**/foo", false, true},
+		{"!/foo/", "sub", "!sub/foo", true, true},
+		{"foo  ", "", "**/foo", false, true},
+		{`foo\ `, "", `**/foo\ `, false, true},
+		{"foo\r", "", "**/foo", false, true},
+	}
+
+	for _, tt := range tests {
+		got, dirOnly, ok := gitignorePattern(tt.line, tt.relDir)
+		if got != tt.want || dirOnly != tt.dirOnly || ok != tt.ok {
+			t.Errorf("gitignorePattern(%q, %q): got %q, %v, %v, want %q, %v, %v",
+				tt.line, tt.relDir, got, dirOnly, ok, tt.want, tt.dirOnly, tt.ok)
+		}
+	}
+}
+
+func TestGitignore(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".gitignore"), "*.log\n!keep.log\n/build\ncache/\n")
+	writeFile(t, filepath.Join(dir, "sub", ".gitignore"), "local.txt\n!/debug.log\n")
+	for _, name := range []string{
+		"a.log", "keep.log", "build/out", "cache/data", "local.txt", ".git/HEAD",
+		"sub/a.log", "sub/debug.log", "sub/build/out", "sub/cache", "sub/local.txt", "sub/deeper/local.txt",
+	} {
+		writeFile(t, filepath.Join(dir, name), name)
+	}
+
+	entries := assemble(t, ContextSource{Path: dir, Dest: ".", Gitignore: true})
+
+	tests := []struct {
+		name     string
+		included bool
+	}{
+		{"a.log", false},
+		{"keep.log", true},
+		{"build", false},
+		{"cache", false},
+		{"local.txt", true},
+		{".git", false},
+		{"sub/a.log", false},
+		{"sub/debug.log", true},
+		{"sub/build/out", true},
+		{"sub/cache", true},
+		{"sub/local.txt", false},
+		{"sub/deeper/local.txt", false},
+	}
+	for _, tt := range tests {
+		if got := HasEntry(entries, tt.name); got != tt.included {
+			t.Errorf("%s: got included %v, want %v", tt.name, got, tt.included)
+		}
+	}
+}
+
+func TestGitignoreWorkTree(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	root := t.TempDir()
+	if out, err := exec.Command("git", "init", "-q", root).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, out)
+	}
+	writeFile(t, filepath.Join(root, ".gitignore"), "*.tmp\n/top.txt\n/app/api/anchored.txt\n")
+	writeFile(t, filepath.Join(root, "app", ".gitignore"), "gen/\n")
+	if err := os.WriteFile(filepath.Join(root, ".git", "info", "exclude"), []byte("secret.txt\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	context := filepath.Join(root, "app", "api")
+	writeFile(t, filepath.Join(context, ".gitignore"), "!keep.tmp\n")
+	for _, name := range []string{"main.go", "x.tmp", "keep.tmp", "gen/file", "secret.txt", "top.txt", "anchored.txt"} {
+		writeFile(t, filepath.Join(context, name), name)
+	}
+
+	entries := assemble(t, ContextSource{Path: context, Dest: ".", Gitignore: true})
+
+	tests := []struct {
+		name     string
+		included bool
+	}{
+		{"main.go", true},
+		{"x.tmp", false},
+		{"keep.tmp", true},
+		{"gen", false},
+		{"secret.txt", false},
+		// anchored to the root of the work tree, not to the context
+		{"top.txt", true},
+		{"anchored.txt", false},
+	}
+	for _, tt := range tests {
+		if got := HasEntry(entries, tt.name); got != tt.included {
+			t.Errorf("%s: got included %v, want %v", tt.name, got, tt.included)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package filesystem
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ignoreMatcher combines the ignore rules of a context source.
+// The .gitignore rules are evaluated first, so .dockerignore and inline rules
+// can re-include paths ignored by git.
+type ignoreMatcher struct {
+	root string
+	// git is nil unless .gitignore files are honored. Its rules are relative
+	// to the root of the work tree, the source is located at gitPrefix.
+	git       *PatternMatcher
+	gitPrefix string
+	docker    *PatternMatcher
+}
+
+// matcher returns the ignore matcher of a directory source.
+func (s ContextSource) matcher() (*ignoreMatcher, error) {
+	m := &ignoreMatcher{root: s.Path}
+
+	ignoreFile := s.IgnoreFile
+	if ignoreFile == "" {
+		ignoreFile = filepath.Join(s.Path, ".dockerignore")
+	}
+
+	var rules []Rule
+	for _, file := range append([]string{ignoreFile}, s.IgnoreFiles...) {
+		r, err := readIgnoreRules(file)
+		if err != nil && !(os.IsNotExist(err) && file == ignoreFile) {
+			return nil, fmt.Errorf("error parsing %s: %w", file, err)
+		}
+		rules = append(rules, r...)
+	}
+	for _, p := range s.Ignore {
+		rules = append(rules, Rule{Pattern: cleanIgnorePattern(p), Origin: "ignore"})
+	}
+
+	var err error
+	if m.docker, err = newRuleMatcher(rules); err != nil {
+		return nil, err
+	}
+
+	if !s.Gitignore {
+		return m, nil
+	}
+
+	// the rules of the whole work tree apply to a source in a subdirectory
+	root, gitDir := s.Path, filepath.Join(s.Path, ".git")
+	if r, rel, dir, ok := gitWorkTree(s.Path); ok {
+		root, m.gitPrefix, gitDir = r, rel, dir
+	}
+	if m.gitPrefix == "." {
+		m.gitPrefix = ""
+	}
+
+	// git never tracks its own directory
+	gitRules := []Rule{{Pattern: ".git", Origin: "gitignore"}}
+	files := []string{gitGlobalExcludesFile(), filepath.Join(gitDir, "info", "exclude")}
+	for _, file := range files {
+		if file == "" {
+			continue
+		}
+		r, err := readGitignoreRules(file, "")
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("error parsing %s: %w", file, err)
+		}
+		gitRules = append(gitRules, r...)
+	}
+
+	// .gitignore files of the parent directories up to the root of the work tree
+	if m.gitPrefix != "" {
+		dirs := strings.Split(m.gitPrefix, "/")
+		for i := range dirs {
+			relDir := path.Join(dirs[:i]...)
+			file := filepath.Join(root, filepath.FromSlash(relDir), ".gitignore")
+			r, err := readGitignoreRules(file, relDir)
+			if err != nil && !os.IsNotExist(err) {
+				return nil, fmt.Errorf("error parsing %s: %w", file, err)
+			}
+			gitRules = append(gitRules, r...)
+		}
+	}
+
+	if m.git, err = newRuleMatcher(gitRules); err != nil {
+		return nil, err
+	}
+	if err := m.enterDir("."); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// enterDir loads the .gitignore file of a directory before its content is walked.
+// Rules of nested .gitignore files are anchored to their directory and take
+// precedence over the rules of parent directories.
+func (m *ignoreMatcher) enterDir(relDir string) error {
+	if m.git == nil {
+		return nil
+	}
+
+	file := filepath.Join(m.root, filepath.FromSlash(relDir), ".gitignore")
+	rules, err := readGitignoreRules(file, path.Join(m.gitPrefix, relDir))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error parsing %s: %w", file, err)
+	}
+	return m.git.add(rules...)
+}
+
+// match reports whether the slash separated path is ignored and by which rule.
+func (m *ignoreMatcher) match(name string, isDir bool) (bool, *Rule) {
+	var matched bool
+	var rule *Rule
+	if m.git != nil {
+		matched, rule = m.git.match(path.Join(m.gitPrefix, name), isDir, false, nil)
+	}
+	return m.docker.match(name, isDir, matched, rule)
+}
+
+// mustDescend reports whether an ignored directory has to be walked anyway.
+func (m *ignoreMatcher) mustDescend(dir string) bool {
+	if m.git != nil && m.git.MustDescend(path.Join(m.gitPrefix, dir)) {
+		return true
+	}
+	return m.docker.MustDescend(dir)
+}
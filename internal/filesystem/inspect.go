@@ -0,0 +1,156 @@
+package filesystem
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// maxKeySniffSize is the largest file that is scanned for private keys.
+const maxKeySniffSize = 64 * 1024
+
+// ContextReport describes the content of an assembled build context.
+type ContextReport struct {
+	Files        int           `json:"files"`
+	Dirs         int           `json:"dirs"`
+	Symlinks     int           `json:"symlinks"`
+	TotalSize    int64         `json:"total_size"`
+	LargestFiles []SizeEntry   `json:"largest_files"`
+	LargestDirs  []SizeEntry   `json:"largest_dirs"`
+	IgnoredBy    []RuleMatches `json:"ignored_by"`
+	Warnings     []Warning     `json:"warnings"`
+}
+
+// SizeEntry is a path of the build context with its size.
+type SizeEntry struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+}
+
+// RuleMatches lists the paths excluded by an ignore rule.
+type RuleMatches struct {
+	Rule  string   `json:"rule"`
+	Paths []string `json:"paths"`
+}
+
+// Warning is suspicious content found in the build context.
+type Warning struct {
+	Name    string `json:"name"`
+	Message string `json:"message"`
+}
+
+// Inspect creates a report of the given entries and ignored paths.
+// top limits the number of largest files and directories in the report.
+func Inspect(entries []Entry, ignored []Ignored, top int) ContextReport {
+	var r ContextReport
+	dirSizes := map[string]int64{}
+
+	for _, e := range entries {
+		switch {
+		case e.IsDir():
+			r.Dirs++
+			r.Warnings = append(r.Warnings, dirWarnings(e)...)
+		case e.Mode.IsRegular():
+			r.Files++
+			r.TotalSize += e.Size
+			r.LargestFiles = append(r.LargestFiles, SizeEntry{Name: e.Name, Size: e.Size})
+			for dir := path.Dir(e.Name); dir != "."; dir = path.Dir(dir) {
+				dirSizes[dir] += e.Size
+			}
+			r.Warnings = append(r.Warnings, fileWarnings(e)...)
+		default:
+			r.Symlinks++
+		}
+	}
+
+	for name, size := range dirSizes {
+		r.LargestDirs = append(r.LargestDirs, SizeEntry{Name: name, Size: size})
+	}
+	r.LargestFiles = largest(r.LargestFiles, top)
+	r.LargestDirs = largest(r.LargestDirs, top)
+
+	byRule := map[string]*RuleMatches{}
+	for _, i := range ignored {
+		rule := i.Rule.String()
+		m, ok := byRule[rule]
+		if !ok {
+			m = &RuleMatches{Rule: rule}
+			byRule[rule] = m
+		}
+		name := i.Name
+		if i.Dir {
+			name += "/"
+		}
+		m.Paths = append(m.Paths, name)
+	}
+	for _, m := range byRule {
+		r.IgnoredBy = append(r.IgnoredBy, *m)
+	}
+	sort.Slice(r.IgnoredBy, func(i, j int) bool { return r.IgnoredBy[i].Rule < r.IgnoredBy[j].Rule })
+
+	return r
+}
+
+// largest returns the top entries with the largest size.
+func largest(entries []SizeEntry, top int) []SizeEntry {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Size == entries[j].Size {
+			return entries[i].Name < entries[j].Name
+		}
+		return entries[i].Size > entries[j].Size
+	})
+	if top >= 0 && len(entries) > top {
+		entries = entries[:top]
+	}
+	return entries
+}
+
+func dirWarnings(e Entry) []Warning {
+	switch path.Base(e.Name) {
+	case ".git":
+		return []Warning{{Name: e.Name, Message: "git repository data is part of the context"}}
+	case "node_modules":
+		return []Warning{{Name: e.Name, Message: "node_modules is part of the context"}}
+	}
+	return nil
+}
+
+func fileWarnings(e Entry) []Warning {
+	base := path.Base(e.Name)
+
+	if base == ".env" || strings.HasPrefix(base, ".env.") {
+		return []Warning{{Name: e.Name, Message: "environment file may contain secrets"}}
+	}
+
+	switch {
+	case strings.HasPrefix(base, "id_rsa"), strings.HasPrefix(base, "id_ecdsa"), strings.HasPrefix(base, "id_ed25519"),
+		strings.HasSuffix(base, ".key"), strings.HasSuffix(base, ".p12"), strings.HasSuffix(base, ".pfx"):
+		if !strings.HasSuffix(base, ".pub") {
+			return []Warning{{Name: e.Name, Message: "file name suggests a private key"}}
+		}
+	}
+
+	if e.Size <= maxKeySniffSize && containsPrivateKey(e.Source) {
+		return []Warning{{Name: e.Name, Message: "file contains a private key"}}
+	}
+	return nil
+}
+
+// containsPrivateKey reports whether the file at path contains a PEM encoded private key.
+func containsPrivateKey(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	b, err := io.ReadAll(io.LimitReader(f, maxKeySniffSize))
+	if err != nil {
+		return false
+	}
+	i := bytes.Index(b, []byte("-----BEGIN "))
+	return i >= 0 && bytes.Contains(b[i:], []byte("PRIVATE KEY-----"))
+}
@@ -0,0 +1,165 @@
+package filesystem
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Manifest records the content of an assembled build context.
+type Manifest struct {
+	Files map[string]ManifestEntry `json:"files"`
+}
+
+// ManifestEntry is a single entry of a manifest.
+type ManifestEntry struct {
+	Mode     fs.FileMode `json:"mode"`
+	Size     int64       `json:"size,omitempty"`
+	Hash     string      `json:"hash,omitempty"`
+	Linkname string      `json:"linkname,omitempty"`
+	// ModTime is the modification time of a file in unix nanoseconds. It is
+	// not part of the digest and only allows to reuse the hash of the file.
+	ModTime int64 `json:"mtime,omitempty"`
+}
+
+// sameContent reports whether e and o describe the same content.
+func (e ManifestEntry) sameContent(o ManifestEntry) bool {
+	e.ModTime, o.ModTime = 0, 0
+	return e == o
+}
+
+// ContextDiff lists the changes between two manifests.
+type ContextDiff struct {
+	Added    []string `json:"added"`
+	Modified []string `json:"modified"`
+	Removed  []string `json:"removed"`
+}
+
+// Empty reports whether there are no changes.
+func (d ContextDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Modified) == 0 && len(d.Removed) == 0
+}
+
+// Manifest hashes every file of the assembled build context.
+func (a *ContextAssembler) Manifest() (Manifest, error) {
+	entries, err := a.Entries()
+	if err != nil {
+		return Manifest{}, err
+	}
+	return ManifestOf(entries, Manifest{})
+}
+
+// ManifestOf hashes every file of entries. The hashes of previous are reused
+// for files with an unchanged mode, size and modification time.
+func ManifestOf(entries []Entry, previous Manifest) (Manifest, error) {
+	m := Manifest{Files: make(map[string]ManifestEntry, len(entries))}
+
+	for _, e := range entries {
+		me := ManifestEntry{
+			Mode:     e.Mode & (fs.ModeType | fs.ModePerm),
+			Size:     e.Size,
+			Linkname: e.Linkname,
+		}
+		if e.Mode.IsRegular() {
+			me.ModTime = e.ModTime.UnixNano()
+			if old, ok := previous.Files[e.Name]; ok && old.Hash != "" && old.ModTime != 0 &&
+				old.Mode == me.Mode && old.Size == me.Size && old.ModTime == me.ModTime {
+				me.Hash = old.Hash
+				m.Files[e.Name] = me
+				continue
+			}
+
+			hash, err := fileHash(e.Source)
+			if err != nil {
+				return Manifest{}, fmt.Errorf("error hashing %s: %w", e.Name, err)
+			}
+			me.Hash = hex.EncodeToString(hash)
+		}
+		m.Files[e.Name] = me
+	}
+
+	return m, nil
+}
+
+// names returns the sorted names of all entries.
+func (m Manifest) names() []string {
+	names := make([]string, 0, len(m.Files))
+	for name := range m.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Digest computes a deterministic sha256 digest of the manifest.
+// The digest covers the name, mode and content hash of every entry in lexical
+// order, followed by any extra values such as build args.
+func (m Manifest) Digest(extra ...string) string {
+	h := sha256.New()
+	for _, name := range m.names() {
+		e := m.Files[name]
+		_, _ = fmt.Fprintf(h, "%s\x00%o\x00%s%s\x00", name, e.Mode, e.Hash, e.Linkname)
+	}
+
+	for _, e := range extra {
+		_, _ = fmt.Fprintf(h, "%s\x00", e)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Diff returns the changes of m compared to previous.
+func (m Manifest) Diff(previous Manifest) ContextDiff {
+	var d ContextDiff
+	for _, name := range m.names() {
+		old, ok := previous.Files[name]
+		switch {
+		case !ok:
+			d.Added = append(d.Added, name)
+		case !old.sameContent(m.Files[name]):
+			d.Modified = append(d.Modified, name)
+		}
+	}
+	for _, name := range previous.names() {
+		if _, ok := m.Files[name]; !ok {
+			d.Removed = append(d.Removed, name)
+		}
+	}
+	return d
+}
+
+// Save writes the manifest to file.
+func (m Manifest) Save(file string) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+
+	// write atomically, a broken manifest would cause needless rebuilds
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, b, 0644); err != nil {
+		return fmt.Errorf("error writing manifest: %w", err)
+	}
+	return os.Rename(tmp, file)
+}
+
+// LoadManifest reads a manifest from file.
+func LoadManifest(file string) (Manifest, error) {
+	var m Manifest
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return m, err
+	}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return m, fmt.Errorf("error reading manifest %s: %w", file, err)
+	}
+	return m, nil
+}
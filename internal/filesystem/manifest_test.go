@@ -0,0 +1,63 @@
+package filesystem
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestManifestOfReusesHashes(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "a.txt")
+	writeFile(t, file, "aaa")
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := os.Chtimes(file, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+
+	previous, err := ManifestOf(assemble(t, ContextSource{Path: dir, Dest: "."}), Manifest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// same size and modification time, the previous hash is trusted
+	writeFile(t, file, "bbb")
+	if err := os.Chtimes(file, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	m, err := ManifestOf(assemble(t, ContextSource{Path: dir, Dest: "."}), previous)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Files["a.txt"].Hash != previous.Files["a.txt"].Hash {
+		t.Errorf("got a new hash for a file with unchanged size and modification time")
+	}
+
+	// a touched file is hashed again
+	modTime = modTime.Add(time.Second)
+	if err := os.Chtimes(file, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	m, err = ManifestOf(assemble(t, ContextSource{Path: dir, Dest: "."}), previous)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Files["a.txt"].Hash == previous.Files["a.txt"].Hash {
+		t.Errorf("got the previous hash for a modified file")
+	}
+	if d := m.Diff(previous); len(d.Modified) != 1 {
+		t.Errorf("got diff %+v, want a.txt modified", d)
+	}
+}
+
+func TestManifestDiffIgnoresModTime(t *testing.T) {
+	previous := Manifest{Files: map[string]ManifestEntry{"a.txt": {Mode: 0644, Size: 1, Hash: "x", ModTime: 1}}}
+	m := Manifest{Files: map[string]ManifestEntry{"a.txt": {Mode: 0644, Size: 1, Hash: "x", ModTime: 2}}}
+	if d := m.Diff(previous); !d.Empty() {
+		t.Errorf("got diff %+v for a touched file with the same content", d)
+	}
+	if m.Digest() != previous.Digest() {
+		t.Errorf("the digest depends on the modification time")
+	}
+}
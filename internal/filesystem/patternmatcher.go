@@ -0,0 +1,258 @@
+package filesystem
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"text/scanner"
+)
+
+// PatternMatcher handles .dockerignore pattern matching.
+// It follows the semantics of Docker's (moby) patternmatcher: patterns are
+// cleaned, evaluated in order, a leading `!` re-includes previously excluded
+// paths and a pattern matching a directory excludes everything below it.
+type PatternMatcher struct {
+	patterns   []*pattern
+	exclusions bool
+}
+
+type matchType int
+
+const (
+	unknownMatch matchType = iota
+	exactMatch
+	prefixMatch
+	suffixMatch
+	regexpMatch
+)
+
+// pattern represents a single .dockerignore pattern
+type pattern struct {
+	rule      Rule
+	val       string
+	isNegated bool
+	matchType matchType
+	regex     *regexp.Regexp
+}
+
+// NewPatternMatcher creates a new pattern matcher from a list of .dockerignore patterns.
+// Patterns are slash separated and relative to the root of the context.
+func NewPatternMatcher(patterns []string) (*PatternMatcher, error) {
+	rules := make([]Rule, 0, len(patterns))
+	for _, p := range patterns {
+		rules = append(rules, Rule{Pattern: p})
+	}
+	return newRuleMatcher(rules)
+}
+
+// newRuleMatcher creates a new pattern matcher from rules.
+func newRuleMatcher(rules []Rule) (*PatternMatcher, error) {
+	pm := &PatternMatcher{
+		patterns: make([]*pattern, 0, len(rules)),
+	}
+	if err := pm.add(rules...); err != nil {
+		return nil, err
+	}
+	return pm, nil
+}
+
+// add appends rules to the matcher. Rules added later take precedence.
+func (pm *PatternMatcher) add(rules ...Rule) error {
+	for _, r := range rules {
+		// Eliminate leading and trailing whitespace.
+		p := strings.TrimSpace(r.Pattern)
+		if p == "" {
+			continue
+		}
+		p = path.Clean(p)
+
+		newp := &pattern{rule: r}
+		if p[0] == '!' {
+			if len(p) == 1 {
+				return fmt.Errorf("%s: illegal exclusion pattern: \"!\"", r)
+			}
+			newp.isNegated = true
+			p = p[1:]
+			pm.exclusions = true
+		}
+
+		// path.Match reports syntax errors like unbalanced brackets
+		if _, err := path.Match(p, "."); err != nil {
+			return fmt.Errorf("%s: %w", r, err)
+		}
+
+		newp.val = p
+		if err := newp.compile(); err != nil {
+			return fmt.Errorf("%s: %w", r, err)
+		}
+		pm.patterns = append(pm.patterns, newp)
+	}
+
+	return nil
+}
+
+// compile determines the cheapest way to match the pattern and converts it to
+// a regular expression if needed.
+func (p *pattern) compile() error {
+	regStr := "^"
+
+	// We use a scanner so we can support utf-8 chars.
+	var scan scanner.Scanner
+	scan.Init(strings.NewReader(p.val))
+
+	p.matchType = exactMatch
+	for i := 0; scan.Peek() != scanner.EOF; i++ {
+		ch := scan.Next()
+
+		switch {
+		case ch == '*':
+			if scan.Peek() != '*' {
+				// "*" matches anything but "/"
+				regStr += "[^/]*"
+				p.matchType = regexpMatch
+				continue
+			}
+
+			// is some flavor of "**"
+			scan.Next()
+
+			// Treat **/ as ** so eat the "/"
+			if scan.Peek() == '/' {
+				scan.Next()
+			}
+
+			if scan.Peek() == scanner.EOF {
+				// "**" at the end matches everything, like .gitignore
+				if p.matchType == exactMatch {
+					p.matchType = prefixMatch
+				} else {
+					regStr += ".*"
+					p.matchType = regexpMatch
+				}
+			} else {
+				// "**" in the middle matches any number of path segments, even zero
+				regStr += "(.*/)?"
+				p.matchType = regexpMatch
+			}
+
+			if i == 0 {
+				p.matchType = suffixMatch
+			}
+		case ch == '?':
+			// "?" is any char except "/"
+			regStr += "[^/]"
+			p.matchType = regexpMatch
+		case shouldEscape(ch):
+			// Escape regexp special chars that have no meaning in a pattern
+			regStr += `\` + string(ch)
+		case ch == '\\':
+			// escape next char, a trailing \ is kept as is
+			if scan.Peek() != scanner.EOF {
+				regStr += `\` + string(scan.Next())
+				p.matchType = regexpMatch
+			} else {
+				regStr += `\\`
+			}
+		case ch == '[' || ch == ']':
+			regStr += string(ch)
+			p.matchType = regexpMatch
+		default:
+			regStr += string(ch)
+		}
+	}
+
+	if p.matchType != regexpMatch {
+		return nil
+	}
+
+	regex, err := regexp.Compile(regStr + "$")
+	if err != nil {
+		return err
+	}
+	p.regex = regex
+	return nil
+}
+
+func shouldEscape(ch rune) bool {
+	return ch == '.' || ch == '+' || ch == '(' || ch == ')' || ch == '{' || ch == '}' || ch == '$' || ch == '|'
+}
+
+func (p *pattern) match(name string) bool {
+	switch p.matchType {
+	case exactMatch:
+		return name == p.val
+	case prefixMatch:
+		// strip trailing **
+		return strings.HasPrefix(name, p.val[:len(p.val)-2])
+	case suffixMatch:
+		// strip leading **
+		suffix := p.val[2:]
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+		// **/foo matches "foo"
+		return suffix[0] == '/' && name == suffix[1:]
+	case regexpMatch:
+		return p.regex.MatchString(name)
+	}
+	return false
+}
+
+// Matches reports whether the slash separated path, or one of its parent
+// directories, is matched by a pattern and not re-included by a subsequent
+// exclusion pattern.
+func (pm *PatternMatcher) Matches(name string) bool {
+	matched, _ := pm.match(name, false, false, nil)
+	return matched
+}
+
+// match evaluates all patterns for name, starting from the result of a
+// previous matcher. It returns the result and the rule that decided it.
+// Directory only rules match name only if isDir is set.
+func (pm *PatternMatcher) match(name string, isDir, matched bool, decidedBy *Rule) (bool, *Rule) {
+	parentPath := path.Dir(name)
+	parentPathDirs := strings.Split(parentPath, "/")
+
+	for _, p := range pm.patterns {
+		// Skip evaluation if this is an inclusion and the path already
+		// matched, or it's an exclusion and the path has not matched yet.
+		if p.isNegated != matched {
+			continue
+		}
+
+		match := (isDir || !p.rule.DirOnly) && p.match(name)
+		if !match && parentPath != "." {
+			// Check to see if the pattern matches one of our parent dirs.
+			for i := range parentPathDirs {
+				if p.match(strings.Join(parentPathDirs[:i+1], "/")) {
+					match = true
+					break
+				}
+			}
+		}
+
+		if match {
+			matched = !p.isNegated
+			decidedBy = &p.rule
+		}
+	}
+
+	return matched, decidedBy
+}
+
+// MustDescend reports whether an ignored directory has to be walked anyway,
+// because an exclusion pattern may re-include a path below it.
+func (pm *PatternMatcher) MustDescend(dir string) bool {
+	if !pm.exclusions {
+		return false
+	}
+
+	dirSlash := dir + "/"
+	for _, p := range pm.patterns {
+		if p.isNegated && strings.HasPrefix(p.val+"/", dirSlash) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+package filesystem
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// The expected results are those of moby's patternmatcher (v0.6.1) with the
+// patterns read like moby's dockerignore.ReadAll does.
+func TestPatternMatcherConformance(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		path     string
+		want     bool
+	}{
+		{"exact", []string{"foo"}, "foo", true},
+		{"exact parent", []string{"foo"}, "foo/bar", true},
+		{"exact other", []string{"foo"}, "bar/foo", false},
+		{"leading slash", []string{"/foo"}, "foo", true},
+		{"leading slash child", []string{"/foo"}, "foo/bar", true},
+		{"leading slash nested", []string{"/foo"}, "bar/foo", false},
+		{"dot dot cleaned", []string{"foo/../bar"}, "bar", true},
+		{"dot dot cleaned other", []string{"foo/../bar"}, "foo/bar", false},
+		{"dot dot above root", []string{"/../foo"}, "foo", true},
+		{"dot slash", []string{"./foo"}, "foo", true},
+		{"star", []string{"*.go"}, "main.go", true},
+		{"star no slash", []string{"*.go"}, "pkg/main.go", false},
+		{"star in dir", []string{"pkg/*.go"}, "pkg/main.go", true},
+		{"dot is literal", []string{"a.b"}, "axb", false},
+		{"double star middle zero", []string{"a/**/b"}, "a/b", true},
+		{"double star middle one", []string{"a/**/b"}, "a/x/b", true},
+		{"double star middle many", []string{"a/**/b"}, "a/x/y/b", true},
+		{"double star middle partial", []string{"a/**/b"}, "a/xb", false},
+		{"double star middle suffix", []string{"a/**/b"}, "a/x/b/c", true},
+		{"double star leading", []string{"**/foo"}, "foo", true},
+		{"double star leading nested", []string{"**/foo"}, "x/y/foo", true},
+		{"double star trailing", []string{"foo/**"}, "foo/x/y", true},
+		{"double star trailing dir", []string{"foo/**"}, "foo", false},
+		{"question mark", []string{"a?c"}, "abc", true},
+		{"question mark no slash", []string{"a?c"}, "a/c", false},
+		{"escaped star", []string{`foo\*bar`}, "foo*bar", true},
+		{"escaped star literal", []string{`foo\*bar`}, "fooxbar", false},
+		{"escaped question mark", []string{`a\?b`}, "a?b", true},
+		{"escaped question mark literal", []string{`a\?b`}, "axb", false},
+		{"character class", []string{"[ab].txt"}, "b.txt", true},
+		{"character class range", []string{"file[0-9]"}, "file7", true},
+		{"negated character class", []string{"[^a]*"}, "b.txt", true},
+		{"negated character class excluded", []string{"[^a]*"}, "a.txt", false},
+		{"exclusion", []string{"*.md", "!README.md"}, "README.md", false},
+		{"exclusion other", []string{"*.md", "!README.md"}, "CHANGES.md", true},
+		{"re-include under excluded dir", []string{"docs", "!docs/keep.md"}, "docs/keep.md", false},
+		{"re-include under excluded dir other", []string{"docs", "!docs/keep.md"}, "docs/other.md", true},
+		{"re-include then exclude again", []string{"docs", "!docs/keep.md", "docs/keep.md"}, "docs/keep.md", true},
+		{"exclusion before pattern", []string{"!keep.md", "*.md"}, "keep.md", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patterns := make([]string, 0, len(tt.patterns))
+			for _, p := range tt.patterns {
+				patterns = append(patterns, cleanIgnorePattern(p))
+			}
+			pm, err := NewPatternMatcher(patterns)
+			if err != nil {
+				t.Fatalf("NewPatternMatcher(%q): %v", tt.patterns, err)
+			}
+			if got := pm.Matches(tt.path); got != tt.want {
+				t.Errorf("patterns %q, path %q: got %v, want %v", tt.patterns, tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPatternMatcherInvalid(t *testing.T) {
+	for _, patterns := range [][]string{{"!"}, {"[a-"}, {"a[b"}} {
+		if _, err := NewPatternMatcher(patterns); err == nil {
+			t.Errorf("NewPatternMatcher(%q): expected an error", patterns)
+		}
+	}
+}
+
+func TestDockerfileDockerignorePrecedence(t *testing.T) {
+	contextDir := t.TempDir()
+	configDir := t.TempDir()
+	dockerfile := filepath.Join(configDir, "Dockerfile")
+
+	writeFile(t, filepath.Join(contextDir, "a.txt"), "a")
+	writeFile(t, filepath.Join(contextDir, "b.txt"), "b")
+	writeFile(t, filepath.Join(contextDir, ".dockerignore"), "a.txt\n")
+	writeFile(t, dockerfile, "FROM scratch\n")
+
+	assemble := func() []Entry {
+		t.Helper()
+		entries, err := NewContextAssembler(ContextSource{
+			Path:       contextDir,
+			Dest:       ".",
+			IgnoreFile: DockerignoreFile(contextDir, dockerfile),
+		}).Entries()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return entries
+	}
+
+	// without a Dockerfile specific file the .dockerignore of the context applies
+	entries := assemble()
+	if HasEntry(entries, "a.txt") || !HasEntry(entries, "b.txt") {
+		t.Errorf(".dockerignore: got %v, want b.txt without a.txt", entryNames(entries))
+	}
+
+	writeFile(t, dockerfile+".dockerignore", "b.txt\n")
+	entries = assemble()
+	if !HasEntry(entries, "a.txt") || HasEntry(entries, "b.txt") {
+		t.Errorf("Dockerfile.dockerignore: got %v, want a.txt without b.txt", entryNames(entries))
+	}
+}
+
+func writeFile(t *testing.T, name, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func entryNames(entries []Entry) []string {
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Name)
+	}
+	return names
+}
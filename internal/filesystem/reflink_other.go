@@ -0,0 +1,10 @@
+//go:build !linux
+
+package filesystem
+
+import "errors"
+
+// reflink is only supported on linux.
+func reflink(src, dst string) error {
+	return errors.ErrUnsupported
+}
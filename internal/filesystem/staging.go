@@ -0,0 +1,243 @@
+package filesystem
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// SyncStats summarizes a sync of a build context into a staging directory.
+type SyncStats struct {
+	Unchanged int
+	Copied    int
+	Linked    int
+	Removed   int
+}
+
+// Sync makes dir an exact copy of the assembled entries of a build context.
+// Unchanged files are kept, changed files are replaced and files that are no
+// longer part of the context are removed. Files are compared by size and
+// modification time first and by content only if those differ.
+// New files are reflinked or hardlinked where the filesystem supports it.
+func Sync(dir string, entries []Entry) (SyncStats, error) {
+	var stats SyncStats
+
+	want := make(map[string]Entry, len(entries))
+	for _, e := range entries {
+		want[e.Name] = e
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return stats, fmt.Errorf("error creating staging directory: %w", err)
+	}
+
+	// remove everything that is gone from the context or changed its type
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == dir {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(dir, p)
+		if err != nil {
+			return fmt.Errorf("error calculating relative path: %w", err)
+		}
+
+		e, ok := want[filepath.ToSlash(relPath)]
+		if ok && e.Mode.Type() == d.Type() {
+			return nil
+		}
+
+		if err := os.RemoveAll(p); err != nil {
+			return fmt.Errorf("error removing %s from staging directory: %w", relPath, err)
+		}
+		stats.Removed++
+		if d.IsDir() {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return stats, err
+	}
+
+	for _, e := range entries {
+		dest := entryPath(dir, e)
+
+		switch {
+		case e.IsDir():
+			if err := os.MkdirAll(dest, 0755); err != nil {
+				return stats, fmt.Errorf("error creating directory %s: %w", e.Name, err)
+			}
+			if err := os.Chmod(dest, e.Mode.Perm()|0700); err != nil {
+				return stats, err
+			}
+
+		case e.Mode&fs.ModeSymlink != 0:
+			if target, err := os.Readlink(dest); err == nil && target == e.Linkname {
+				stats.Unchanged++
+				continue
+			}
+			_ = os.Remove(dest)
+			if err := os.Symlink(e.Linkname, dest); err != nil {
+				return stats, fmt.Errorf("error creating symlink %s: %w", e.Name, err)
+			}
+			stats.Copied++
+
+		case e.Mode.IsRegular():
+			unchanged, err := stagedFileUnchanged(e, dest)
+			if err != nil {
+				return stats, err
+			}
+			if unchanged {
+				stats.Unchanged++
+				continue
+			}
+
+			linked, err := stageFile(e, dest)
+			if err != nil {
+				return stats, fmt.Errorf("error staging %s: %w", e.Name, err)
+			}
+			if linked {
+				stats.Linked++
+			} else {
+				stats.Copied++
+			}
+		}
+	}
+
+	return stats, nil
+}
+
+// stagedFileUnchanged reports whether the staged file at dest matches the entry.
+func stagedFileUnchanged(e Entry, dest string) (bool, error) {
+	staged, err := os.Lstat(dest)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if !staged.Mode().IsRegular() || staged.Size() != e.Size {
+		return false, nil
+	}
+
+	source, err := os.Stat(e.Source)
+	if err != nil {
+		return false, err
+	}
+
+	// hardlinked files are the source itself
+	if os.SameFile(source, staged) {
+		return true, nil
+	}
+
+	if staged.Mode().Perm() != e.Mode.Perm() {
+		return false, nil
+	}
+
+	if staged.ModTime().Equal(e.ModTime) {
+		return true, nil
+	}
+
+	same, err := sameContent(e.Source, dest)
+	if err != nil || !same {
+		return false, err
+	}
+
+	// remember the source modification time to skip hashing next time
+	return true, os.Chtimes(dest, e.ModTime, e.ModTime)
+}
+
+// stageFile places the source file of e at dest. It prefers a reflink, then a
+// hardlink and falls back to a plain copy. It reports whether the file was linked.
+func stageFile(e Entry, dest string) (bool, error) {
+	tmp := dest + ".devx-tmp"
+	_ = os.Remove(tmp)
+
+	// links must point to the file itself, not to a followed symlink
+	source, err := filepath.EvalSymlinks(e.Source)
+	if err != nil {
+		return false, err
+	}
+
+	if err := reflink(source, tmp); err == nil {
+		if err := finishStagedFile(e, tmp, dest); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	if err := os.Link(source, tmp); err == nil {
+		// the link shares mode and times with the source and must not be modified
+		return true, os.Rename(tmp, dest)
+	}
+
+	if err := copyFileContents(source, tmp); err != nil {
+		os.Remove(tmp)
+		return false, err
+	}
+	return false, finishStagedFile(e, tmp, dest)
+}
+
+// finishStagedFile sets mode and times of the staged copy and moves it into place.
+func finishStagedFile(e Entry, tmp, dest string) error {
+	if err := os.Chmod(tmp, e.Mode.Perm()); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Chtimes(tmp, e.ModTime, e.ModTime); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dest)
+}
+
+// copyFileContents copies the contents of src into the newly created dst.
+func copyFileContents(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// sameContent reports whether the files a and b have the same content.
+func sameContent(a, b string) (bool, error) {
+	ha, err := fileHash(a)
+	if err != nil {
+		return false, err
+	}
+	hb, err := fileHash(b)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(ha, hb), nil
+}
+
+// fileHash returns the sha256 hash of the file at path.
+func fileHash(path string) ([]byte, error) {
+	h := sha256.New()
+	if err := hashFile(h, path); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
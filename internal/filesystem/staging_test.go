@@ -0,0 +1,85 @@
+package filesystem
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSync(t *testing.T) {
+	src := sourceTree(t)
+	entries := assemble(t, ContextSource{Path: src, Dest: "."})
+	before := snapshot(t, src)
+
+	// stale content of a previous sync is replaced or removed
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "run.sh"), "stale")
+	writeFile(t, filepath.Join(dir, "gone", "b.txt"), "b")
+	symlink(t, "gone", filepath.Join(dir, "link"))
+
+	stats, err := Sync(dir, entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Removed != 1 {
+		t.Errorf("got %d removed, want 1", stats.Removed)
+	}
+	checkTree(t, dir, entries, false)
+	if _, err := os.Lstat(filepath.Join(dir, "gone")); !os.IsNotExist(err) {
+		t.Errorf("gone: got %v, want it removed", err)
+	}
+
+	stats, err = Sync(dir, entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (SyncStats{Unchanged: 3}); stats != want {
+		t.Errorf("second sync: got %+v, want %+v", stats, want)
+	}
+	checkTree(t, dir, entries, false)
+
+	if after := snapshot(t, src); !reflect.DeepEqual(before, after) {
+		t.Errorf("source changed by the sync:\nbefore %v\nafter  %v", before, after)
+	}
+}
+
+type snapshotEntry struct {
+	Mode     fs.FileMode
+	ModTime  time.Time
+	Content  string
+	Linkname string
+}
+
+// snapshot returns the state of every file below dir.
+func snapshot(t *testing.T, dir string) map[string]snapshotEntry {
+	t.Helper()
+	files := map[string]snapshotEntry{}
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		s := snapshotEntry{Mode: info.Mode(), ModTime: info.ModTime()}
+		switch {
+		case info.Mode()&fs.ModeSymlink != 0:
+			s.Linkname, err = os.Readlink(p)
+		case info.Mode().IsRegular():
+			var b []byte
+			b, err = os.ReadFile(p)
+			s.Content = string(b)
+		}
+		files[p] = s
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
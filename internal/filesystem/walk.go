@@ -0,0 +1,126 @@
+package filesystem
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// specialFileModes are file types that cannot be part of a build context.
+const specialFileModes = fs.ModeNamedPipe | fs.ModeSocket | fs.ModeDevice | fs.ModeCharDevice | fs.ModeIrregular
+
+// sourceWalker collects the entries of a directory source.
+type sourceWalker struct {
+	source  ContextSource
+	dest    string
+	matcher *ignoreMatcher
+	entries map[string]Entry
+	ignored *[]Ignored
+}
+
+// walk adds the content of dir, located at rel inside the source, to the entries.
+// Symlinks are kept as links unless the source follows them. realDirs holds the
+// resolved paths of the directories being walked to detect symlink loops.
+func (w *sourceWalker) walk(dir, rel string, realDirs []string) error {
+	children, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("error reading directory: %w", err)
+	}
+
+	for _, child := range children {
+		p := filepath.Join(dir, child.Name())
+		relPath := path.Join(rel, child.Name())
+		name := path.Join(w.dest, relPath)
+
+		info, err := os.Lstat(p)
+		if err != nil {
+			return fmt.Errorf("error getting file info: %w", err)
+		}
+
+		var linkname, realDir string
+		if info.Mode()&fs.ModeSymlink != 0 {
+			if linkname, err = os.Readlink(p); err != nil {
+				return fmt.Errorf("error reading symlink: %w", err)
+			}
+
+			if w.source.FollowSymlinks {
+				target, err := os.Stat(p)
+				switch {
+				case err != nil:
+					logrus.Warnf("Keeping dangling symlink %s: %v", p, err)
+				case target.IsDir():
+					if realDir, err = filepath.EvalSymlinks(p); err != nil {
+						return fmt.Errorf("error resolving symlink: %w", err)
+					}
+					if symlinkLoop(realDir, realDirs) {
+						logrus.Warnf("Skipping symlink %s, it points to a parent directory", p)
+						continue
+					}
+					info, linkname = target, ""
+				default:
+					info, linkname = target, ""
+				}
+			}
+		}
+
+		// ignored directories are still walked if an exclusion may re-include their content
+		if ok, rule := w.matcher.match(relPath, info.IsDir()); ok {
+			if !info.IsDir() || !w.matcher.mustDescend(relPath) {
+				*w.ignored = append(*w.ignored, Ignored{Name: name, Dir: info.IsDir(), Rule: *rule})
+				continue
+			}
+		} else if info.Mode()&specialFileModes != 0 {
+			logrus.Warnf("Skipping %s, a %s cannot be part of a build context", p, specialFileType(info.Mode()))
+			continue
+		} else if err := addEntry(w.entries, newEntry(name, p, info, linkname)); err != nil {
+			return err
+		}
+
+		if !info.IsDir() {
+			continue
+		}
+
+		if err := w.matcher.enterDir(relPath); err != nil {
+			return err
+		}
+
+		if realDir == "" {
+			realDir = filepath.Join(realDirs[len(realDirs)-1], child.Name())
+		}
+		if err := w.walk(p, relPath, append(realDirs, realDir)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// specialFileType describes the type of a special file.
+func specialFileType(mode fs.FileMode) string {
+	switch {
+	case mode&fs.ModeNamedPipe != 0:
+		return "named pipe"
+	case mode&fs.ModeSocket != 0:
+		return "socket"
+	case mode&(fs.ModeDevice|fs.ModeCharDevice) != 0:
+		return "device"
+	default:
+		return "special file"
+	}
+}
+
+// symlinkLoop reports whether following a symlink to realDir would walk one of
+// the directories in realDirs, or one of their parents, again.
+func symlinkLoop(realDir string, realDirs []string) bool {
+	for _, d := range realDirs {
+		if d == realDir || strings.HasPrefix(d, realDir+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
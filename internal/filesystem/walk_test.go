@@ -0,0 +1,116 @@
+package filesystem
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestWalkSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.txt"), "abc")
+	writeFile(t, filepath.Join(dir, "sub", "b.txt"), "b")
+	symlink(t, "a.txt", filepath.Join(dir, "link"))
+	symlink(t, "sub", filepath.Join(dir, "dirlink"))
+
+	tests := []struct {
+		name   string
+		follow bool
+	}{
+		{"kept", false},
+		{"followed", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries := assemble(t, ContextSource{Path: dir, Dest: ".", FollowSymlinks: tt.follow})
+
+			link, _ := FindEntry(entries, "link")
+			dirLink, _ := FindEntry(entries, "dirlink")
+			if tt.follow {
+				if !link.Mode.IsRegular() || link.Linkname != "" || link.Size != 3 {
+					t.Errorf("link: got mode %v, linkname %q, size %d, want the regular file a.txt", link.Mode, link.Linkname, link.Size)
+				}
+				if !dirLink.IsDir() || !HasEntry(entries, "dirlink/b.txt") {
+					t.Errorf("dirlink: got %v, want the content of sub", entryNames(entries))
+				}
+				return
+			}
+
+			if link.Mode&fs.ModeSymlink == 0 || link.Linkname != "a.txt" {
+				t.Errorf("link: got mode %v, linkname %q, want a symlink to a.txt", link.Mode, link.Linkname)
+			}
+			if dirLink.Mode&fs.ModeSymlink == 0 || dirLink.Linkname != "sub" || HasEntry(entries, "dirlink/b.txt") {
+				t.Errorf("dirlink: got mode %v, entries %v, want a symlink to sub without content", dirLink.Mode, entryNames(entries))
+			}
+		})
+	}
+}
+
+func TestWalkSymlinkLoop(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "sub", "a.txt"), "a")
+	symlink(t, "..", filepath.Join(dir, "sub", "parent"))
+	symlink(t, ".", filepath.Join(dir, "sub", "self"))
+
+	logs := captureLogs(t)
+
+	done := make(chan []Entry)
+	go func() {
+		done <- assemble(t, ContextSource{Path: dir, Dest: ".", FollowSymlinks: true})
+	}()
+
+	var entries []Entry
+	select {
+	case entries = <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("walking a symlink loop did not finish")
+	}
+
+	for _, name := range []string{"sub/parent", "sub/self"} {
+		if HasEntry(entries, name) {
+			t.Errorf("got entry %s, want the symlink loop to be skipped", name)
+		}
+		if !strings.Contains(logs.String(), filepath.Join(dir, name)) {
+			t.Errorf("no warning about the symlink loop %s in %q", name, logs.String())
+		}
+	}
+	if !HasEntry(entries, "sub/a.txt") {
+		t.Errorf("got %v, want sub/a.txt", entryNames(entries))
+	}
+}
+
+// assemble returns the entries of sources and fails the test on errors.
+func assemble(t *testing.T, sources ...ContextSource) []Entry {
+	t.Helper()
+	entries, err := NewContextAssembler(sources...).Entries()
+	if err != nil {
+		t.Error(err)
+	}
+	return entries
+}
+
+func symlink(t *testing.T, target, name string) {
+	t.Helper()
+	if err := os.Symlink(target, name); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// captureLogs returns a buffer receiving the output of the standard logger
+// until the test ends.
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	logger := logrus.StandardLogger()
+	out := logger.Out
+	logger.SetOutput(&buf)
+	t.Cleanup(func() { logger.SetOutput(out) })
+	return &buf
+}
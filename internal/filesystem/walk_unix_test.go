@@ -0,0 +1,37 @@
+//go:build unix
+
+package filesystem
+
+import (
+	"net"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestWalkSkipsSpecialFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.txt"), "a")
+
+	if err := syscall.Mkfifo(filepath.Join(dir, "fifo"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	l, err := net.Listen("unix", filepath.Join(dir, "sock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	logs := captureLogs(t)
+	entries := assemble(t, ContextSource{Path: dir, Dest: "."})
+
+	if got := entryNames(entries); len(got) != 1 || got[0] != "a.txt" {
+		t.Errorf("got %v, want only a.txt", got)
+	}
+	for _, want := range []string{"a named pipe", "a socket"} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("no warning about %s in %q", want, logs.String())
+		}
+	}
+}
@@ -0,0 +1,92 @@
+// Package git reads the state of git work trees.
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Info is the state of a git work tree.
+type Info struct {
+	// Commit is the full SHA of HEAD.
+	Commit string
+	// Branch is the checked out branch, empty for a detached HEAD.
+	Branch string
+	// Dirty reports uncommitted changes of tracked or untracked files.
+	Dirty bool
+	// Source is the web URL of the origin remote, if any.
+	Source string
+}
+
+// ShortCommit returns the abbreviated commit SHA.
+func (i Info) ShortCommit() string {
+	if len(i.Commit) > 12 {
+		return i.Commit[:12]
+	}
+	return i.Commit
+}
+
+// Inspect returns the state of the git work tree containing dir. ok is false
+// if dir is not inside a work tree or git is not installed.
+func Inspect(dir string) (info Info, ok bool, err error) {
+	if out, err := run(dir, "rev-parse", "--is-inside-work-tree"); err != nil || out != "true" {
+		return info, false, nil
+	}
+
+	if info.Commit, err = run(dir, "rev-parse", "HEAD"); err != nil {
+		// a repository without commits
+		return info, false, nil
+	}
+	if branch, err := run(dir, "rev-parse", "--abbrev-ref", "HEAD"); err == nil && branch != "HEAD" {
+		info.Branch = branch
+	}
+
+	status, err := run(dir, "status", "--porcelain")
+	if err != nil {
+		return info, false, err
+	}
+	info.Dirty = status != ""
+
+	if remote, err := run(dir, "config", "--get", "remote.origin.url"); err == nil {
+		info.Source = sourceURL(remote)
+	}
+
+	return info, true, nil
+}
+
+func run(dir string, args ...string) (string, error) {
+	out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w", strings.Join(args, " "), err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// sourceURL turns a remote URL into a web URL without credentials,
+// e.g. git@github.com:org/repo.git into https://github.com/org/repo.
+func sourceURL(remote string) string {
+	remote = strings.TrimSuffix(remote, ".git")
+
+	if rest, ok := strings.CutPrefix(remote, "git@"); ok {
+		host, path, _ := strings.Cut(rest, ":")
+		return "https://" + host + "/" + path
+	}
+	for _, scheme := range []string{"ssh://", "https://", "http://"} {
+		rest, ok := strings.CutPrefix(remote, scheme)
+		if !ok {
+			continue
+		}
+		if _, hostPath, ok := strings.Cut(rest, "@"); ok {
+			rest = hostPath
+		}
+		if scheme == "ssh://" {
+			// drop the ssh port
+			host, path, _ := strings.Cut(rest, "/")
+			host, _, _ = strings.Cut(host, ":")
+			return "https://" + host + "/" + path
+		}
+		return scheme + rest
+	}
+	return remote
+}
@@ -0,0 +1,196 @@
+// Package history persists records of past builds.
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/zenginechris/devx/cli"
+	"github.com/zenginechris/devx/internal/buildkit"
+)
+
+// maxFileSize is the size of the store above which the oldest records are
+// dropped. The newest records that fit into half of it are kept.
+const maxFileSize = 8 << 20
+
+// Build outcomes.
+const (
+	StatusSuccess = "success"
+	StatusFailed  = "failed"
+	// StatusSkipped is a build skipped because nothing changed.
+	StatusSkipped = "skipped"
+)
+
+// Record is a single build.
+type Record struct {
+	ID          string            `json:"id"`
+	Project     string            `json:"project"`
+	Image       string            `json:"image,omitempty"`
+	Start       time.Time         `json:"start"`
+	End         time.Time         `json:"end"`
+	Stages      []cli.StageTiming `json:"stages,omitempty"`
+	Status      string            `json:"status"`
+	Error       string            `json:"error,omitempty"`
+	KubeContext string            `json:"kube_context,omitempty"`
+	LogFile     string            `json:"log_file,omitempty"`
+
+	// Steps are the timings of the Dockerfile instructions.
+	Steps []buildkit.StepTiming `json:"steps,omitempty"`
+	// ImageSize is the total size of the layers of the image.
+	ImageSize int64   `json:"image_size,omitempty"`
+	Layers    []Layer `json:"layers,omitempty"`
+}
+
+// Layer is a layer of the built image with the instruction that created it.
+type Layer struct {
+	CreatedBy string `json:"created_by"`
+	Size      int64  `json:"size"`
+}
+
+// Duration returns the duration of the build.
+func (r Record) Duration() time.Duration {
+	return r.End.Sub(r.Start)
+}
+
+// NewID returns a new random record id.
+func NewID() string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Store is an append-only file of build records, one JSON object per line.
+// The oldest records are dropped once the file exceeds its maximum size.
+type Store struct {
+	file    string
+	maxSize int64
+}
+
+// NewStore returns the store in file.
+func NewStore(file string) *Store {
+	return &Store{file: file, maxSize: maxFileSize}
+}
+
+// Append adds a record to the store.
+func (s *Store) Append(r Record) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(s.file, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("error opening build history: %w", err)
+	}
+	// a single write keeps concurrent builds from interleaving lines
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("error writing build history: %w", err)
+	}
+	info, statErr := f.Stat()
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("error writing build history: %w", err)
+	}
+	if statErr == nil && info.Size() > s.maxSize {
+		return s.compact()
+	}
+	return nil
+}
+
+// compact drops the oldest records, keeping the newest that fit into half of
+// the maximum size. The file is replaced atomically.
+func (s *Store) compact() error {
+	b, err := os.ReadFile(s.file)
+	if err != nil {
+		return fmt.Errorf("error reading build history: %w", err)
+	}
+
+	lines := bytes.Split(bytes.TrimRight(b, "\n"), []byte("\n"))
+	// the newest record is kept even if it is larger
+	keep := len(lines) - 1
+	size := int64(len(lines[keep])) + 1
+	for keep > 0 && size+int64(len(lines[keep-1]))+1 <= s.maxSize/2 {
+		keep--
+		size += int64(len(lines[keep])) + 1
+	}
+
+	tmp := s.file + ".tmp"
+	kept := append(bytes.Join(lines[keep:], []byte("\n")), '\n')
+	if err := os.WriteFile(tmp, kept, 0644); err != nil {
+		return fmt.Errorf("error compacting build history: %w", err)
+	}
+	return os.Rename(tmp, s.file)
+}
+
+// Records returns all records, oldest first. Lines that cannot be parsed,
+// e.g. of an interrupted write, are skipped.
+func (s *Store) Records() ([]Record, error) {
+	f, err := os.Open(s.file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading build history: %w", err)
+	}
+	defer f.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			continue
+		}
+		records = append(records, r)
+	}
+	return records, scanner.Err()
+}
+
+// Find returns the record with id or a unique prefix of it.
+func (s *Store) Find(id string) (Record, error) {
+	records, err := s.Records()
+	if err != nil {
+		return Record{}, err
+	}
+
+	var found []Record
+	for _, r := range records {
+		if r.ID == id {
+			return r, nil
+		}
+		if strings.HasPrefix(r.ID, id) {
+			found = append(found, r)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return Record{}, fmt.Errorf("no build with id %s", id)
+	case 1:
+		return found[0], nil
+	default:
+		return Record{}, fmt.Errorf("id %s is ambiguous, it matches %d builds", id, len(found))
+	}
+}
+
+// Previous returns the latest record of project started before t that has one of statuses.
+func (s *Store) Previous(project string, t time.Time, statuses ...string) (Record, bool, error) {
+	records, err := s.Records()
+	if err != nil {
+		return Record{}, false, err
+	}
+	for _, r := range slices.Backward(records) {
+		if r.Project == project && r.Start.Before(t) && slices.Contains(statuses, r.Status) {
+			return r, true, nil
+		}
+	}
+	return Record{}, false, nil
+}
@@ -0,0 +1,59 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestStoreCompaction(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "history.jsonl")
+	s := NewStore(file)
+	s.maxSize = 2048
+
+	for i := range 100 {
+		if err := s.Append(Record{ID: strconv.Itoa(i), Project: "api", Status: StatusSuccess}); err != nil {
+			t.Fatal(err)
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Size() > s.maxSize {
+			t.Fatalf("after %d records: got %d bytes, want at most %d", i+1, info.Size(), s.maxSize)
+		}
+	}
+
+	records, err := s.Records()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) == 0 || len(records) == 100 {
+		t.Fatalf("got %d records, want the oldest dropped", len(records))
+	}
+	for i, r := range records {
+		if want := strconv.Itoa(100 - len(records) + i); r.ID != want {
+			t.Errorf("record %d: got id %s, want %s", i, r.ID, want)
+		}
+	}
+}
+
+func TestStoreKeepsLargeRecord(t *testing.T) {
+	s := NewStore(filepath.Join(t.TempDir(), "history.jsonl"))
+	s.maxSize = 64
+
+	for _, id := range []string{"1", "2"} {
+		if err := s.Append(Record{ID: id, Project: "api", Error: string(make([]byte, 100))}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	records, err := s.Records()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].ID != "2" {
+		t.Errorf("got %+v, want only the newest record", records)
+	}
+}
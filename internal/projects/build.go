@@ -0,0 +1,141 @@
+package projects
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+)
+
+// BuildConfig configures how the image of a project is built.
+type BuildConfig struct {
+	// Builder is the name of the buildx builder. It defaults to the current builder.
+	Builder string `toml:"builder,omitempty" json:"builder,omitempty"`
+	// Dockerfile is the path to the Dockerfile, relative to the project config path.
+	// It defaults to the Dockerfile in the project config path.
+	Dockerfile string `toml:"dockerfile,omitempty" json:"dockerfile,omitempty"`
+	// Target is the build stage to build.
+	Target string `toml:"target,omitempty" json:"target,omitempty"`
+	// Args are additional build args.
+	Args map[string]string `toml:"args,omitempty" json:"args,omitempty"`
+	// Labels are additional image labels.
+	Labels map[string]string `toml:"labels,omitempty" json:"labels,omitempty"`
+	// Platforms are the target platforms, e.g. linux/arm64.
+	Platforms []string `toml:"platforms,omitempty" json:"platforms,omitempty"`
+	// Secrets are exposed to RUN --mount=type=secret instructions.
+	Secrets []BuildSecret `toml:"secrets,omitempty,inline" json:"secrets,omitempty"`
+	// SSH are SSH agent sockets or keys exposed to RUN --mount=type=ssh
+	// instructions, e.g. default or id=/path/to/key.
+	SSH []string `toml:"ssh,omitempty" json:"ssh,omitempty"`
+	// Cache configures the build cache.
+	Cache BuildCache `toml:"cache,omitempty" json:"cache,omitempty"`
+}
+
+// Build cache modes.
+const (
+	// CacheModeLocal keeps the build cache in a directory per project below the devx cache directory.
+	CacheModeLocal = "local"
+	// CacheModeRegistry keeps the build cache in a registry image.
+	CacheModeRegistry = "registry"
+	// CacheModeInline embeds the build cache in the built image.
+	CacheModeInline = "inline"
+	// CacheModeNone disables the build cache.
+	CacheModeNone = "none"
+)
+
+// BuildCache configures where the build cache of a project is kept.
+type BuildCache struct {
+	// Mode is one of local (default), registry, inline or none.
+	Mode string `toml:"mode,omitempty" json:"mode,omitempty"`
+	// Ref is the registry image of the cache. It is required by the registry
+	// mode and the image the inline mode imports the cache from.
+	Ref string `toml:"ref,omitempty" json:"ref,omitempty"`
+}
+
+// CacheMode returns the cache mode, defaulting to local.
+func (c BuildCache) CacheMode() (string, error) {
+	switch c.Mode {
+	case "":
+		return CacheModeLocal, nil
+	case CacheModeLocal, CacheModeInline, CacheModeNone:
+		return c.Mode, nil
+	case CacheModeRegistry:
+		if c.Ref == "" {
+			return "", fmt.Errorf("the registry cache mode requires a ref")
+		}
+		return c.Mode, nil
+	default:
+		return "", fmt.Errorf("invalid cache mode '%s', expected %s, %s, %s or %s",
+			c.Mode, CacheModeLocal, CacheModeRegistry, CacheModeInline, CacheModeNone)
+	}
+}
+
+// BuildSecret is a build secret read from a file or an environment variable.
+type BuildSecret struct {
+	ID  string `toml:"id" json:"id"`
+	Src string `toml:"src,omitempty" json:"src,omitempty"`
+	Env string `toml:"env,omitempty" json:"env,omitempty"`
+}
+
+// SrcPath returns the path of the secret file, relative paths are resolved
+// against the project config path.
+func (s BuildSecret) SrcPath(project Project) string {
+	if s.Src == "" || filepath.IsAbs(s.Src) {
+		return s.Src
+	}
+	return filepath.Join(project.ConfigPath, s.Src)
+}
+
+// Flag returns the value of the --secret flag of the builder.
+func (s BuildSecret) Flag(project Project) (string, error) {
+	switch {
+	case s.ID == "":
+		return "", fmt.Errorf("build secret is missing an id")
+	case s.Src != "" && s.Env != "":
+		return "", fmt.Errorf("build secret '%s' must have either src or env, not both", s.ID)
+	case s.Src != "":
+		return fmt.Sprintf("id=%s,src=%s", s.ID, s.SrcPath(project)), nil
+	case s.Env != "":
+		return fmt.Sprintf("id=%s,env=%s", s.ID, s.Env), nil
+	default:
+		return "", fmt.Errorf("build secret '%s' must have src or env", s.ID)
+	}
+}
+
+// DockerfilePath returns the path of the Dockerfile of the project.
+func (p Project) DockerfilePath() string {
+	switch {
+	case p.Build.Dockerfile == "":
+		return filepath.Join(p.ConfigPath, "Dockerfile")
+	case filepath.IsAbs(p.Build.Dockerfile):
+		return p.Build.Dockerfile
+	default:
+		return filepath.Join(p.ConfigPath, p.Build.Dockerfile)
+	}
+}
+
+// GeneratedFile is a file rendered from a text/template into the build context.
+// The template receives the BuildVars of the build.
+type GeneratedFile struct {
+	// Template is the template file, relative to the project config path.
+	Template string `toml:"template" json:"template"`
+	// Dest is the slash separated path of the file in the build context.
+	Dest string `toml:"dest" json:"dest"`
+}
+
+// TemplatePath returns the path of the template of f for project.
+func (f GeneratedFile) TemplatePath(project Project) string {
+	if filepath.IsAbs(f.Template) {
+		return f.Template
+	}
+	return filepath.Join(project.ConfigPath, f.Template)
+}
+
+// SortedKeys returns the keys of m in lexical order.
+func SortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,34 @@
+package projects
+
+import "testing"
+
+func TestBuildSecretFlag(t *testing.T) {
+	project := Project{ConfigPath: "/home/dev/.config/devx/projects/api"}
+
+	tests := []struct {
+		name    string
+		secret  BuildSecret
+		want    string
+		wantErr bool
+	}{
+		{"absolute src", BuildSecret{ID: "npmrc", Src: "/home/dev/.npmrc"}, "id=npmrc,src=/home/dev/.npmrc", false},
+		{"relative src", BuildSecret{ID: "npmrc", Src: "npmrc"}, "id=npmrc,src=/home/dev/.config/devx/projects/api/npmrc", false},
+		{"relative parent src", BuildSecret{ID: "npmrc", Src: "../shared/npmrc"}, "id=npmrc,src=/home/dev/.config/devx/projects/shared/npmrc", false},
+		{"env", BuildSecret{ID: "token", Env: "GITHUB_TOKEN"}, "id=token,env=GITHUB_TOKEN", false},
+		{"missing id", BuildSecret{Src: "npmrc"}, "", true},
+		{"src and env", BuildSecret{ID: "token", Src: "npmrc", Env: "GITHUB_TOKEN"}, "", true},
+		{"neither src nor env", BuildSecret{ID: "token"}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.secret.Flag(project)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
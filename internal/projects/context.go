@@ -0,0 +1,91 @@
+package projects
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/pelletier/go-toml/v2/unstable"
+)
+
+// ContextEntry is an additional build context.
+// It is configured either as a plain path or as a table with a destination
+// inside the build context, additional ignore patterns and an ignore file:
+//
+//	contexts = ['../shared', { src = '../proto', dest = 'libs/proto', ignore = ['*.md'], ignore_file = 'proto.dockerignore' }]
+type ContextEntry struct {
+	// Src is the file or directory on disk.
+	Src string `toml:"src" json:"src"`
+	// Dest is the path inside the build context, defaults to the base name of Src.
+	Dest string `toml:"dest,omitempty" json:"dest,omitempty"`
+	// Ignore are additional .dockerignore patterns for the entry.
+	Ignore []string `toml:"ignore,omitempty" json:"ignore,omitempty"`
+	// IgnoreFile is a .dockerignore formatted file for the entry, relative to
+	// the project config path.
+	IgnoreFile string `toml:"ignore_file,omitempty" json:"ignore_file,omitempty"`
+}
+
+// IgnoreFilePath returns the path of the ignore file of the entry for project.
+func (c ContextEntry) IgnoreFilePath(project Project) string {
+	if c.IgnoreFile == "" || filepath.IsAbs(c.IgnoreFile) {
+		return c.IgnoreFile
+	}
+	return filepath.Join(project.ConfigPath, c.IgnoreFile)
+}
+
+// UnmarshalTOML implements unstable.Unmarshaler to accept both the plain string
+// and the table form of an entry.
+func (c *ContextEntry) UnmarshalTOML(node *unstable.Node) error {
+	switch node.Kind {
+	case unstable.String:
+		*c = ContextEntry{Src: string(node.Data)}
+		return nil
+	case unstable.InlineTable:
+	default:
+		return fmt.Errorf("context entry must be a string or a table, got %s", node.Kind)
+	}
+
+	*c = ContextEntry{}
+	it := node.Children()
+	for it.Next() {
+		kv := it.Node()
+		key := kv.Key()
+		if !key.Next() {
+			continue
+		}
+		name := string(key.Node().Data)
+		value := kv.Value()
+
+		switch name {
+		case "src", "dest", "ignore_file":
+			if value.Kind != unstable.String {
+				return fmt.Errorf("context entry key '%s' must be a string", name)
+			}
+			switch name {
+			case "src":
+				c.Src = string(value.Data)
+			case "dest":
+				c.Dest = string(value.Data)
+			default:
+				c.IgnoreFile = string(value.Data)
+			}
+		case "ignore":
+			if value.Kind != unstable.Array {
+				return fmt.Errorf("context entry key 'ignore' must be an array of strings")
+			}
+			values := value.Children()
+			for values.Next() {
+				if values.Node().Kind != unstable.String {
+					return fmt.Errorf("context entry key 'ignore' must be an array of strings")
+				}
+				c.Ignore = append(c.Ignore, string(values.Node().Data))
+			}
+		default:
+			return fmt.Errorf("unknown context entry key '%s'", name)
+		}
+	}
+
+	if c.Src == "" {
+		return fmt.Errorf("context entry is missing 'src'")
+	}
+	return nil
+}
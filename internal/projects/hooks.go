@@ -0,0 +1,74 @@
+package projects
+
+import (
+	"path/filepath"
+	"strconv"
+)
+
+// Hooks are commands run at points of the build of a project.
+type Hooks struct {
+	// PreBuild runs before the build context is assembled, e.g. go generate.
+	PreBuild []Hook `toml:"pre_build,omitempty,inline" json:"pre_build,omitempty"`
+	// PostBuild runs after the image was built.
+	PostBuild []Hook `toml:"post_build,omitempty,inline" json:"post_build,omitempty"`
+	// PostDeploy runs after the deployment was updated, e.g. a smoke check.
+	PostDeploy []Hook `toml:"post_deploy,omitempty,inline" json:"post_deploy,omitempty"`
+	// OnFailure runs when the build or a hook failed.
+	OnFailure []Hook `toml:"on_failure,omitempty,inline" json:"on_failure,omitempty"`
+}
+
+// Hook is a shell command.
+type Hook struct {
+	Command string `toml:"command" json:"command"`
+	// Dir is the working directory, relative to the project context. It defaults to the context.
+	Dir string `toml:"dir,omitempty" json:"dir,omitempty"`
+	// Env are additional environment variables.
+	Env map[string]string `toml:"env,omitempty" json:"env,omitempty"`
+	// NonFatal logs a warning instead of failing the build if the command fails.
+	NonFatal bool `toml:"non_fatal,omitempty" json:"non_fatal,omitempty"`
+}
+
+// WorkDir returns the working directory of the hook for project.
+func (h Hook) WorkDir(project Project) string {
+	if filepath.IsAbs(h.Dir) {
+		return h.Dir
+	}
+	return filepath.Join(project.Context, h.Dir)
+}
+
+// BuildVars are the variables of a build. Hooks receive them as DEVX_
+// environment variables. Variables that are not known yet are empty, e.g.
+// the image in pre_build hooks.
+type BuildVars struct {
+	Project    string
+	Namespace  string
+	Deployment string
+	Context    string
+	// Image is the image reference including the tag.
+	Image     string
+	ImageTag  string
+	Digest    string
+	Commit    string
+	Branch    string
+	Dirty     bool
+	BuildID   string
+	BuildTime string
+}
+
+// Env returns the variables as environment variables.
+func (v BuildVars) Env() []string {
+	return []string{
+		"DEVX_PROJECT=" + v.Project,
+		"DEVX_NAMESPACE=" + v.Namespace,
+		"DEVX_DEPLOYMENT=" + v.Deployment,
+		"DEVX_CONTEXT=" + v.Context,
+		"DEVX_IMAGE=" + v.Image,
+		"DEVX_IMAGE_TAG=" + v.ImageTag,
+		"DEVX_DIGEST=" + v.Digest,
+		"DEVX_COMMIT=" + v.Commit,
+		"DEVX_BRANCH=" + v.Branch,
+		"DEVX_DIRTY=" + strconv.FormatBool(v.Dirty),
+		"DEVX_BUILD_ID=" + v.BuildID,
+		"DEVX_BUILD_TIME=" + v.BuildTime,
+	}
+}
@@ -1,12 +1,51 @@
 package projects
 
+const (
+	// ContextModeStream streams the build context as a tar archive to the builder.
+	ContextModeStream = "stream"
+	// ContextModeDir copies the build context into a temporary directory.
+	ContextModeDir = "dir"
+	// ContextModeStaging syncs the build context into a persistent staging directory.
+	ContextModeStaging = "staging"
+)
+
 type (
 	Project struct {
-		Name           string   `toml:"name" json:"name"`
-		Context        string   `toml:"context" json:"context"`
-		ConfigPath     string   `toml:"config_path" json:"config_path"`
-		Contexts       []string `toml:"contexts" json:"contexts"`
-		DeploymentName string   `toml:"deployment_name" json:"deployment_name"`
-		Namespace      string   `toml:"namespace" json:"namespace"`
+		Name           string         `toml:"name" json:"name"`
+		Context        string         `toml:"context" json:"context"`
+		ConfigPath     string         `toml:"config_path" json:"config_path"`
+		Contexts       []ContextEntry `toml:"contexts,inline" json:"contexts"`
+		DeploymentName string         `toml:"deployment_name" json:"deployment_name"`
+		Namespace      string         `toml:"namespace" json:"namespace"`
+		ContextMode    string         `toml:"context_mode,omitempty" json:"context_mode,omitempty"`
+		// Gitignore honors .gitignore files in addition to .dockerignore.
+		Gitignore bool `toml:"gitignore,omitempty" json:"gitignore,omitempty"`
+		// Ignore are additional .dockerignore patterns for the context.
+		Ignore []string `toml:"ignore,omitempty" json:"ignore,omitempty"`
+		// FollowSymlinks adds the targets of symlinks to the context instead of the links.
+		FollowSymlinks bool `toml:"follow_symlinks,omitempty" json:"follow_symlinks,omitempty"`
+		// TagTemplate is a text/template of the image tag, see TagData for the variables.
+		TagTemplate string `toml:"tag_template,omitempty" json:"tag_template,omitempty"`
+		// KeepImages is the number of images kept after each deploy, overriding
+		// the global setting. Older images not used in the cluster are removed.
+		KeepImages int `toml:"keep_images,omitempty" json:"keep_images,omitempty"`
+		// MaxImageSize is the maximum image size, e.g. 500MB.
+		MaxImageSize string `toml:"max_image_size,omitempty" json:"max_image_size,omitempty"`
+		// MaxImageSizeAction is warn (default) or fail when the image exceeds MaxImageSize.
+		MaxImageSizeAction string `toml:"max_image_size_action,omitempty" json:"max_image_size_action,omitempty"`
+		// Build configures the image build.
+		Build BuildConfig `toml:"build,omitempty" json:"build,omitempty"`
+		// Lint configures the Dockerfile checks.
+		Lint LintConfig `toml:"lint,omitempty" json:"lint,omitempty"`
+		// Hooks are commands run before the build and after the deploy.
+		Hooks Hooks `toml:"hooks,omitempty" json:"hooks,omitempty"`
+		// Generate are files rendered into the build context before the build.
+		Generate []GeneratedFile `toml:"generate,omitempty,inline" json:"generate,omitempty"`
+	}
+
+	// LintConfig configures the Dockerfile checks of devx lint and the build.
+	LintConfig struct {
+		// Disable are the IDs of the rules that are not checked.
+		Disable []string `toml:"disable,omitempty" json:"disable,omitempty"`
 	}
 )
@@ -0,0 +1,63 @@
+package projects
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// DefaultTagTemplate is the image tag template of projects without a git work tree.
+const DefaultTagTemplate = "{{.Digest}}"
+
+// DefaultGitTagTemplate is the image tag template of projects in a git work tree.
+const DefaultGitTagTemplate = "{{.Commit}}{{if .Dirty}}-dirty{{end}}-{{.Digest}}"
+
+var (
+	validTag       = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,127}$`)
+	invalidTagChar = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+)
+
+// TagData are the variables of an image tag template.
+type TagData struct {
+	// Project is the slug of the project name.
+	Project string
+	// Digest is the abbreviated digest of the build context and settings.
+	Digest string
+	// Commit is the abbreviated commit SHA, empty outside of git work trees.
+	Commit string
+	// Branch is the branch with characters invalid in tags replaced by -.
+	Branch string
+	// Dirty reports uncommitted changes in the work tree.
+	Dirty bool
+	// Timestamp is the build time in unix milliseconds.
+	Timestamp int64
+}
+
+// ImageTag renders the tag template of the project. The default template
+// depends on whether data carries a commit.
+func (p Project) ImageTag(data TagData) (string, error) {
+	text := p.TagTemplate
+	if text == "" {
+		text = DefaultTagTemplate
+		if data.Commit != "" {
+			text = DefaultGitTagTemplate
+		}
+	}
+	data.Branch = invalidTagChar.ReplaceAllString(data.Branch, "-")
+
+	tmpl, err := template.New("tag").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid tag template: %w", err)
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("invalid tag template: %w", err)
+	}
+
+	tag := sb.String()
+	if !validTag.MatchString(tag) {
+		return "", fmt.Errorf("tag template '%s' renders the invalid tag '%s'", text, tag)
+	}
+	return tag, nil
+}